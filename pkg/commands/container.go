@@ -1,21 +1,34 @@
 package commands
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
 	"fmt"
+	"os"
 	"os/exec"
+	"slices"
+	"sort"
+	"strconv"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/docker/docker/api/types/container"
 	"github.com/sasha-s/go-deadlock"
 
 	dockerTypes "github.com/docker/docker/api/types"
 	"github.com/docker/docker/api/types/filters"
+	"github.com/docker/docker/api/types/image"
+	"github.com/docker/docker/api/types/mount"
 	"github.com/docker/docker/client"
+	"github.com/docker/docker/errdefs"
 	"github.com/go-errors/errors"
 	"github.com/jesseduffield/lazydocker/pkg/i18n"
 	"github.com/jesseduffield/lazydocker/pkg/utils"
+	"github.com/samber/lo"
 	"github.com/sirupsen/logrus"
+	"golang.org/x/term"
 	"golang.org/x/xerrors"
 )
 
@@ -59,10 +72,22 @@ func (c *Container) Remove(options container.RemoveOptions) error {
 	return nil
 }
 
-// Stop stops the container
-func (c *Container) Stop() error {
+// Stop stops the container, giving it the grace period from StopTimeout
+func (c *Container) Stop(defaultTimeout *int) error {
 	c.Log.Warn(fmt.Sprintf("stopping container %s", c.Name))
-	return c.Client.ContainerStop(context.Background(), c.ID, container.StopOptions{})
+	return c.Client.ContainerStop(context.Background(), c.ID, container.StopOptions{Timeout: c.StopTimeout(defaultTimeout)})
+}
+
+// StopTimeout returns how many seconds the container should be given to stop
+// before being killed. That's what the container was configured with (via
+// --stop-timeout) if anything, and otherwise the given default. If that's nil
+// too, so is the result, and the daemon's default applies
+func (c *Container) StopTimeout(defaultTimeout *int) *int {
+	if !c.DetailsLoaded() || c.Details.Config == nil || c.Details.Config.StopTimeout == nil {
+		return defaultTimeout
+	}
+
+	return c.Details.Config.StopTimeout
 }
 
 // Pause pauses the container
@@ -119,17 +144,410 @@ func (c *Container) Top(ctx context.Context) (container.ContainerTopOKBody, erro
 	return c.Client.ContainerTop(ctx, c.ID, []string{})
 }
 
+// StreamTop polls the processes running in the given container at the given
+// interval, for a live process view. The first poll happens straight away, and
+// if it fails we return its error. The channel is closed once the context is
+// done, or if a later poll fails (e.g. because the container has stopped).
+func (c *DockerCommand) StreamTop(ctx context.Context, nameOrID string, interval time.Duration) (<-chan container.ContainerTopOKBody, error) {
+	return pollStream(ctx, c.Log, interval, true, func(ctx context.Context) (container.ContainerTopOKBody, error) {
+		return c.Client.ContainerTop(ctx, nameOrID, []string{})
+	})
+}
+
 // PruneContainers prunes containers
 func (c *DockerCommand) PruneContainers() error {
 	_, err := c.Client.ContainersPrune(context.Background(), filters.Args{})
 	return err
 }
 
+// dockerRunFailedExitCode is the exit code `docker run` uses when the error was
+// with docker itself rather than the command we ran in the container
+const dockerRunFailedExitCode = 125
+
+// RunAndCapture runs a one-off container from the given image, returning what it
+// wrote to stdout and its exit code. The container is removed once it exits. A
+// non-zero exit code from the command isn't treated as an error.
+func (c *DockerCommand) RunAndCapture(image string, cmd []string) (string, int, error) {
+	if err := ValidateImageRef(image); err != nil {
+		return "", -1, err
+	}
+
+	args := append([]string{"run", "--rm", image}, cmd...)
+	c.Log.Warn(fmt.Sprintf("running one-off container for image %s", image))
+
+	output, err := c.OSCommand.NewCmd("docker", args...).Output()
+	if err != nil {
+		var exitError *exec.ExitError
+		if errors.As(err, &exitError) && exitError.ExitCode() != dockerRunFailedExitCode {
+			return string(output), exitError.ExitCode(), nil
+		}
+		_, err = sanitisedCommandOutput(output, err)
+		return "", -1, err
+	}
+
+	return string(output), 0, nil
+}
+
+// FailureInfo describes a container that exited with a non-zero exit code
+type FailureInfo struct {
+	ContainerID string
+	Name        string
+	ExitCode    int
+	FinishedAt  time.Time
+	// LastLines are the last few lines the container logged before exiting
+	LastLines []string
+}
+
+// failureLogLines is how many lines of logs we include with each failure
+const failureLogLines = 5
+
+// RecentFailures returns up to limit containers that exited with a non-zero exit
+// code, most recently finished first, along with the last few lines they logged.
+// We only find out when a container finished by inspecting it, so to avoid
+// inspecting every exited container we pick the limit most recently created
+// failures from the list, then order those by when they finished. This means
+// a long-lived container that failed recently can lose out to newer ones.
+func (c *DockerCommand) RecentFailures(limit int) ([]FailureInfo, error) {
+	ctx := context.Background()
+	exited, err := c.listExitedContainers()
+	if err != nil {
+		return nil, err
+	}
+	successes, err := c.listExitedContainers(exitClassCodes["success"]...)
+	if err != nil {
+		return nil, err
+	}
+
+	containers := lo.Filter(exited, func(ctr dockerTypes.Container, _ int) bool {
+		return !lo.ContainsBy(successes, func(success dockerTypes.Container) bool { return success.ID == ctr.ID })
+	})
+	sort.SliceStable(containers, func(i, j int) bool {
+		return containers[i].Created > containers[j].Created
+	})
+	if limit >= 0 && len(containers) > limit {
+		containers = containers[:limit]
+	}
+
+	failures := []FailureInfo{}
+	for _, ctr := range containers {
+		details, err := c.Client.ContainerInspect(ctx, ctr.ID)
+		if err != nil {
+			c.Log.Error(err)
+			continue
+		}
+		if details.State == nil || details.State.ExitCode == 0 {
+			continue
+		}
+
+		finishedAt, _ := time.Parse(time.RFC3339Nano, details.State.FinishedAt)
+		failures = append(failures, FailureInfo{
+			ContainerID: ctr.ID,
+			Name:        strings.TrimLeft(details.Name, "/"),
+			ExitCode:    details.State.ExitCode,
+			FinishedAt:  finishedAt,
+		})
+	}
+
+	sort.SliceStable(failures, func(i, j int) bool {
+		return failures[i].FinishedAt.After(failures[j].FinishedAt)
+	})
+
+	for i := range failures {
+		logs, err := c.GetLogTail(failures[i].ContainerID, LogTailOptions{
+			Tail:      fmt.Sprintf("%d", failureLogLines),
+			StripANSI: true,
+		})
+		if err != nil {
+			c.Log.Error(err)
+			continue
+		}
+		failures[i].LastLines = utils.SplitLines(logs)
+	}
+
+	return failures, nil
+}
+
+// exitClassCodes are the exit codes making up each class, other than "error"
+// which is every other exit code. Containers stopped by a signal, such as by
+// `docker stop` or the OOM killer, exit with 128 plus the signal number: 143
+// for SIGTERM and 137 for SIGKILL. We count those as "killed" rather than
+// "error".
+var exitClassCodes = map[string][]int{
+	"success": {0},
+	"killed":  {137, 143},
+}
+
+// listExitedContainers lists the exited containers, only including those that
+// exited with one of the given exit codes if any are given. We leave the
+// filtering to docker rather than parsing the human-readable status.
+func (c *DockerCommand) listExitedContainers(exitCodes ...int) ([]dockerTypes.Container, error) {
+	filterArgs := filters.NewArgs(filters.Arg("status", "exited"))
+	for _, exitCode := range exitCodes {
+		filterArgs.Add("exited", strconv.Itoa(exitCode))
+	}
+
+	return c.Client.ContainerList(context.Background(), container.ListOptions{
+		All:     true,
+		Filters: filterArgs,
+	})
+}
+
+// ContainersByExitClass returns the exited containers whose exit code falls in
+// the given class: "success" (exit code 0), "killed" (137 or 143, i.e. stopped
+// by SIGKILL or SIGTERM) or "error" (any other non-zero exit code)
+func (c *DockerCommand) ContainersByExitClass(class string) ([]*Container, error) {
+	if class != "success" && class != "error" && class != "killed" {
+		return nil, errors.Errorf("unknown exit class %q, expected success, error or killed", class)
+	}
+
+	var containers []dockerTypes.Container
+	if class == "error" {
+		// docker can only filter for the exit codes we want, not the ones we
+		// don't, so we take away everything in the other classes
+		exited, err := c.listExitedContainers()
+		if err != nil {
+			return nil, err
+		}
+		nonErrors, err := c.listExitedContainers(append(exitClassCodes["success"], exitClassCodes["killed"]...)...)
+		if err != nil {
+			return nil, err
+		}
+		containers = lo.Filter(exited, func(ctr dockerTypes.Container, _ int) bool {
+			return !lo.ContainsBy(nonErrors, func(nonError dockerTypes.Container) bool { return nonError.ID == ctr.ID })
+		})
+	} else {
+		var err error
+		containers, err = c.listExitedContainers(exitClassCodes[class]...)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	result := []*Container{}
+	for _, ctr := range containers {
+		newContainer := c.newContainer(ctr.ID)
+		setContainerSummary(newContainer, ctr)
+		result = append(result, newContainer)
+	}
+
+	return result, nil
+}
+
+// composeProjectServices maps each compose project to the services that still
+// have a container in it
+type composeProjectServices map[string]map[string]bool
+
+// composeDependencies returns the services a compose container depends on,
+// from compose's depends_on label, e.g. "db:service_started:false,cache:..."
+func composeDependencies(labels map[string]string) []string {
+	dependsOn := labels["com.docker.compose.depends_on"]
+	if dependsOn == "" {
+		return []string{}
+	}
+
+	return lo.Map(strings.Split(dependsOn, ","), func(dependency string, _ int) string {
+		return strings.SplitN(dependency, ":", 2)[0]
+	})
+}
+
+// composeProjectGone tells us whether the compose project a container belongs
+// to has gone, leaving the container behind. See OrphanedContainers.
+func composeProjectGone(labels map[string]string, services composeProjectServices, checkConfigFiles bool) bool {
+	project := labels["com.docker.compose.project"]
+	if project == "" {
+		return false
+	}
+
+	for _, dependency := range composeDependencies(labels) {
+		if !services[project][dependency] {
+			return true
+		}
+	}
+
+	if !checkConfigFiles || labels["com.docker.compose.project.config_files"] == "" {
+		return false
+	}
+	for _, configFile := range strings.Split(labels["com.docker.compose.project.config_files"], ",") {
+		if _, err := os.Stat(configFile); !os.IsNotExist(err) {
+			return false
+		}
+	}
+	return true
+}
+
+// OrphanedContainers returns containers that are likely left over and safe to
+// clean up. A container counts as orphaned when any of these hold:
+//   - the image it was created from no longer exists locally
+//   - it belongs to a compose project and depends on a service (per compose's
+//     depends_on label) that no longer has any container in that project,
+//     meaning the rest of the project was taken down without it
+//   - it belongs to a compose project none of whose compose files exist any
+//     more. We can only check this when the daemon is local, because the
+//     files' paths are on the daemon's machine.
+//
+// Note that a project with a single service is not orphaned just because it
+// has a single container.
+func (c *DockerCommand) OrphanedContainers() ([]*Container, error) {
+	ctx := context.Background()
+
+	containers, err := c.Client.ContainerList(ctx, container.ListOptions{All: true})
+	if err != nil {
+		return nil, err
+	}
+
+	images, err := c.Client.ImageList(ctx, image.ListOptions{All: true})
+	if err != nil {
+		return nil, err
+	}
+
+	imageIDs := make(map[string]bool, len(images))
+	for _, img := range images {
+		imageIDs[img.ID] = true
+	}
+
+	services := composeProjectServices{}
+	for _, ctr := range containers {
+		project := ctr.Labels["com.docker.compose.project"]
+		if project == "" {
+			continue
+		}
+		if services[project] == nil {
+			services[project] = map[string]bool{}
+		}
+		services[project][ctr.Labels["com.docker.compose.service"]] = true
+	}
+
+	checkConfigFiles := daemonIsLocal(c.Client.DaemonHost())
+
+	result := []*Container{}
+	for _, ctr := range containers {
+		if !imageIDs[ctr.ImageID] || composeProjectGone(ctr.Labels, services, checkConfigFiles) {
+			newContainer := c.newContainer(ctr.ID)
+			setContainerSummary(newContainer, ctr)
+			result = append(result, newContainer)
+		}
+	}
+
+	return result, nil
+}
+
+// restartOrderDelay is how long we wait between restarts when restarting
+// containers in order, to give each one a moment to come up before the next
+// one (which may depend on it) restarts
+var restartOrderDelay = 2 * time.Second
+
+// RestartResult is the outcome of restarting one container
+type RestartResult struct {
+	ID  string
+	Err error
+}
+
+// RestartContainers restarts the given containers, returning a result for each
+// in the order given. When ordered is true the containers are restarted one at
+// a time in that order, with a short delay between each; otherwise they're all
+// restarted at once. We keep going when a restart fails and return all the
+// errors at the end.
+func (c *DockerCommand) RestartContainers(ids []string, ordered bool) ([]RestartResult, error) {
+	restart := func(id string) error {
+		return c.Client.ContainerRestart(context.Background(), id, container.StopOptions{})
+	}
+
+	results := make([]RestartResult, len(ids))
+	if ordered {
+		for i, id := range ids {
+			if i > 0 {
+				time.Sleep(restartOrderDelay)
+			}
+			results[i] = RestartResult{ID: id, Err: restart(id)}
+		}
+	} else {
+		var wg sync.WaitGroup
+		for i, id := range ids {
+			wg.Add(1)
+			go func(i int, id string) {
+				defer wg.Done()
+				results[i] = RestartResult{ID: id, Err: restart(id)}
+			}(i, id)
+		}
+		wg.Wait()
+	}
+
+	errs := []error{}
+	for _, result := range results {
+		if result.Err != nil {
+			errs = append(errs, errors.Errorf("%s: %v", result.ID, result.Err))
+		}
+	}
+
+	return results, errors.Join(errs...)
+}
+
+// RunForegroundCmd returns a command that runs a throwaway container from the
+// given image attached to the terminal, for quick interactive tasks. The
+// container is removed when it exits. It's up to the caller to run this as a
+// subprocess.
+func (c *DockerCommand) RunForegroundCmd(image string, cmd []string) (*exec.Cmd, error) {
+	if err := ValidateImageRef(image); err != nil {
+		return nil, err
+	}
+
+	args := append([]string{"run", "--rm", "-it", image}, cmd...)
+	return c.OSCommand.NewCmd("docker", args...), nil
+}
+
 // Inspect returns details about the container
 func (c *Container) Inspect() (dockerTypes.ContainerJSON, error) {
 	return c.Client.ContainerInspect(context.Background(), c.ID)
 }
 
+// ExportInspect returns the container's inspect output exactly as docker gives
+// it to us (rather than via our parsed Details), pretty-printed, so that it can
+// be copied into a bug report
+func (c *DockerCommand) ExportInspect(nameOrID string) ([]byte, error) {
+	_, raw, err := c.Client.ContainerInspectWithRaw(context.Background(), nameOrID, false)
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	if err := json.Indent(&buf, raw, "", "  "); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// execShell is the shell we use when execing into a container
+const execShell = "/bin/sh"
+
+// CanExec tells us whether we'll be able to exec into the given container, and
+// if not, why not, so that the UI can disable the exec action rather than fail.
+// Distroless images, for example, have no shell for us to run.
+func (c *DockerCommand) CanExec(nameOrID string) (bool, string, error) {
+	ctx := context.Background()
+
+	details, err := c.Client.ContainerInspect(ctx, nameOrID)
+	if err != nil {
+		return false, "", err
+	}
+
+	if details.State == nil || !details.State.Running {
+		return false, "container is not running", nil
+	}
+	if details.State.Paused {
+		return false, "container is paused", nil
+	}
+
+	if _, err := c.Client.ContainerStatPath(ctx, nameOrID, execShell); err != nil {
+		if errdefs.IsNotFound(err) {
+			return false, "container has no " + execShell, nil
+		}
+		return false, "", err
+	}
+
+	return true, "", nil
+}
+
 // RenderTop returns details about the container
 func (c *Container) RenderTop(ctx context.Context) (string, error) {
 	result, err := c.Top(ctx)
@@ -146,3 +564,309 @@ func (c *Container) RenderTop(ctx context.Context) (string, error) {
 func (c *Container) DetailsLoaded() bool {
 	return c.Details.ContainerJSONBase != nil
 }
+
+// UsesImage tells us whether the container was created from the given image,
+// matching the same way as ContainersForImage
+func (c *Container) UsesImage(imageID string) bool {
+	return containerUsesImage(c.Container.ImageID, c.Container.Image, imageID)
+}
+
+// CgroupParent returns the cgroup the container's cgroup was created under, or
+// an empty string if the daemon default is being used
+func (c *Container) CgroupParent() string {
+	if !c.DetailsLoaded() || c.Details.HostConfig == nil {
+		return ""
+	}
+
+	return c.Details.HostConfig.CgroupParent
+}
+
+// CgroupNamespaceMode returns whether the container has its own cgroup
+// namespace ("private") or shares the host's ("host"). Older daemons don't
+// report this, in which case we assume private
+func (c *Container) CgroupNamespaceMode() string {
+	if !c.DetailsLoaded() || c.Details.HostConfig == nil || c.Details.HostConfig.CgroupnsMode == "" {
+		return string(container.CgroupnsModePrivate)
+	}
+
+	return string(c.Details.HostConfig.CgroupnsMode)
+}
+
+// ShmSize returns the size of the container's /dev/shm in human-readable form,
+// or an empty string if we don't know it yet
+func (c *Container) ShmSize() string {
+	if !c.DetailsLoaded() || c.Details.HostConfig == nil || c.Details.HostConfig.ShmSize == 0 {
+		return ""
+	}
+
+	return utils.FormatBinaryBytes(int(c.Details.HostConfig.ShmSize))
+}
+
+// TmpfsMounts returns the container's tmpfs mounts, mapping each mount path to
+// its options (e.g. "size=64m,mode=1777")
+func (c *Container) TmpfsMounts() map[string]string {
+	if !c.DetailsLoaded() || c.Details.HostConfig == nil || c.Details.HostConfig.Tmpfs == nil {
+		return map[string]string{}
+	}
+
+	return c.Details.HostConfig.Tmpfs
+}
+
+// Devices returns the host devices the container has been given access to (via
+// --device), for auditing hardware access
+func (c *Container) Devices() []container.DeviceMapping {
+	if !c.DetailsLoaded() || c.Details.HostConfig == nil || c.Details.HostConfig.Devices == nil {
+		return []container.DeviceMapping{}
+	}
+
+	return c.Details.HostConfig.Devices
+}
+
+// ContainerMount is one of a container's mounts, along with whether its source
+// is missing on the host
+type ContainerMount struct {
+	dockerTypes.MountPoint
+
+	// SourceMissing is true for bind mounts whose host path no longer exists,
+	// which will stop the container from starting again. We can only check the
+	// path when the daemon shares our filesystem, so for remote daemons (and
+	// e.g. Docker Desktop on Windows) this is always false.
+	SourceMissing bool
+}
+
+// daemonIsLocal tells us whether the daemon at the given host is running on
+// this machine and sees the same filesystem as we do. We only trust unix
+// sockets for this: tcp and ssh hosts are usually remote, and a named pipe
+// means Docker Desktop on Windows, whose bind sources are paths inside its VM.
+func daemonIsLocal(host string) bool {
+	return strings.HasPrefix(host, "unix://")
+}
+
+// requireLocalDaemon returns an error saying we can't do the given thing unless
+// the daemon is local, for when we'd otherwise be looking at paths the daemon
+// gave us on the wrong machine
+func (c *DockerCommand) requireLocalDaemon(action string) error {
+	if host := c.Client.DaemonHost(); !daemonIsLocal(host) {
+		return errors.Errorf("%s needs a local docker daemon, but docker is at %s", action, host)
+	}
+
+	return nil
+}
+
+// Mounts returns the container's mounts, flagging bind mounts with a missing
+// source when the daemon is local
+func (c *Container) Mounts() []ContainerMount {
+	if !c.DetailsLoaded() {
+		return []ContainerMount{}
+	}
+
+	checkSources := c.Client != nil && daemonIsLocal(c.Client.DaemonHost())
+
+	return lo.Map(c.Details.Mounts, func(mountPoint dockerTypes.MountPoint, _ int) ContainerMount {
+		sourceMissing := false
+		if checkSources && mountPoint.Type == mount.TypeBind {
+			_, err := os.Stat(mountPoint.Source)
+			sourceMissing = os.IsNotExist(err)
+		}
+		return ContainerMount{MountPoint: mountPoint, SourceMissing: sourceMissing}
+	})
+}
+
+// MemorySwap returns the container's combined memory plus swap limit, in human
+// readable form. Docker treats 0 as "twice the memory limit", which we report
+// as "default", and -1 as no limit.
+func (c *Container) MemorySwap() string {
+	if !c.DetailsLoaded() || c.Details.HostConfig == nil || c.Details.HostConfig.MemorySwap == 0 {
+		return "default"
+	}
+
+	if c.Details.HostConfig.MemorySwap < 0 {
+		return "unlimited"
+	}
+
+	return utils.FormatBinaryBytes(int(c.Details.HostConfig.MemorySwap))
+}
+
+// MemorySwappiness returns how readily the kernel will swap out the container's
+// memory (0-100), or "default" if it inherits the host's setting
+func (c *Container) MemorySwappiness() string {
+	if !c.DetailsLoaded() || c.Details.HostConfig == nil || c.Details.HostConfig.MemorySwappiness == nil {
+		return "default"
+	}
+
+	return strconv.FormatInt(*c.Details.HostConfig.MemorySwappiness, 10)
+}
+
+// CapabilitySummary renders the capabilities added to and dropped from the
+// container's default set, e.g. "+NET_ADMIN -MKNOD", or "default" if neither
+// --cap-add nor --cap-drop was used
+func (c *Container) CapabilitySummary() string {
+	if !c.DetailsLoaded() || c.Details.HostConfig == nil {
+		return "default"
+	}
+
+	// docker accepts capabilities with or without the CAP_ prefix
+	normalise := func(capability string) string {
+		return strings.TrimPrefix(strings.ToUpper(capability), "CAP_")
+	}
+
+	parts := []string{}
+	for _, capability := range c.Details.HostConfig.CapAdd {
+		parts = append(parts, "+"+normalise(capability))
+	}
+	for _, capability := range c.Details.HostConfig.CapDrop {
+		parts = append(parts, "-"+normalise(capability))
+	}
+
+	if len(parts) == 0 {
+		return "default"
+	}
+
+	return strings.Join(parts, " ")
+}
+
+// ConsoleSize returns the TTY height and width the container was created with,
+// or zeroes if it wasn't given one
+func (c *Container) ConsoleSize() (uint, uint) {
+	if !c.DetailsLoaded() || c.Details.HostConfig == nil {
+		return 0, 0
+	}
+
+	return c.Details.HostConfig.ConsoleSize[0], c.Details.HostConfig.ConsoleSize[1]
+}
+
+// execResizeOptions works out what size an exec's TTY should be: the
+// container's configured console size if it has one, otherwise the size of our
+// own terminal
+func execResizeOptions(consoleHeight uint, consoleWidth uint, getTerminalSize func() (int, int, error)) (container.ResizeOptions, error) {
+	if consoleHeight != 0 && consoleWidth != 0 {
+		return container.ResizeOptions{Height: consoleHeight, Width: consoleWidth}, nil
+	}
+
+	width, height, err := getTerminalSize()
+	if err != nil {
+		return container.ResizeOptions{}, err
+	}
+
+	return container.ResizeOptions{Height: uint(height), Width: uint(width)}, nil
+}
+
+// ResizeExecTTY resizes the TTY of an interactive exec in the given container
+// so that it matches the container's console size, or our terminal's size
+func (c *DockerCommand) ResizeExecTTY(ctr *Container, execID string) error {
+	height, width := ctr.ConsoleSize()
+	options, err := execResizeOptions(height, width, func() (int, int, error) {
+		return term.GetSize(int(os.Stdout.Fd()))
+	})
+	if err != nil {
+		return WrapError(err)
+	}
+
+	return c.Client.ContainerExecResize(context.Background(), execID, options)
+}
+
+// PortProtocol returns the protocol of a container's port (tcp, udp or sctp),
+// defaulting to tcp like docker does when none is given
+func PortProtocol(port dockerTypes.Port) string {
+	if port.Type == "" {
+		return "tcp"
+	}
+
+	return port.Type
+}
+
+// PortURLs returns http URLs for the container's published tcp ports, for
+// opening in a browser. UDP and SCTP ports are skipped because they can't be
+// serving http.
+func (c *Container) PortURLs() []string {
+	urls := []string{}
+	for _, port := range c.Container.Ports {
+		if port.PublicPort == 0 || port.IP == "" || PortProtocol(port) != "tcp" {
+			continue
+		}
+
+		ip := port.IP
+		if ip == "0.0.0.0" || ip == "::" {
+			ip = "localhost"
+		} else if strings.Contains(ip, ":") {
+			ip = "[" + ip + "]"
+		}
+		urls = append(urls, fmt.Sprintf("http://%s:%d/", ip, port.PublicPort))
+	}
+
+	return urls
+}
+
+// ExtraHosts returns the `hostname:ip` entries added to the container's
+// /etc/hosts via --add-host
+func (c *Container) ExtraHosts() []string {
+	if !c.DetailsLoaded() || c.Details.HostConfig == nil || c.Details.HostConfig.ExtraHosts == nil {
+		return []string{}
+	}
+
+	return c.Details.HostConfig.ExtraHosts
+}
+
+// Entrypoint returns the entrypoint the container was actually started with
+func (c *Container) Entrypoint() []string {
+	if !c.DetailsLoaded() || c.Details.Config == nil {
+		return []string{}
+	}
+
+	return c.Details.Config.Entrypoint
+}
+
+// EntrypointOverridden tells us whether the container's entrypoint differs from
+// the default declared by its image, as happens with `docker run --entrypoint`
+func (c *Container) EntrypointOverridden() (bool, error) {
+	if !c.DetailsLoaded() || c.Details.Config == nil {
+		return false, errors.New("container details have not been loaded")
+	}
+
+	img, _, err := c.Client.ImageInspectWithRaw(context.Background(), c.Details.Image)
+	if err != nil {
+		return false, err
+	}
+
+	var imageEntrypoint []string
+	if img.Config != nil {
+		imageEntrypoint = img.Config.Entrypoint
+	}
+
+	return !slices.Equal(c.Details.Config.Entrypoint, imageEntrypoint), nil
+}
+
+// ContainerLabelOverrides returns the labels that were set on the container
+// when it was created (e.g. with --label or by compose), as opposed to being
+// inherited from its image. Labels the image also sets but with a different
+// value count as overrides.
+func (c *DockerCommand) ContainerLabelOverrides(nameOrID string) (map[string]string, error) {
+	ctx := context.Background()
+
+	details, err := c.Client.ContainerInspect(ctx, nameOrID)
+	if err != nil {
+		return nil, err
+	}
+
+	img, _, err := c.Client.ImageInspectWithRaw(ctx, details.Image)
+	if err != nil {
+		return nil, err
+	}
+
+	imageLabels := map[string]string{}
+	if img.Config != nil && img.Config.Labels != nil {
+		imageLabels = img.Config.Labels
+	}
+
+	overrides := map[string]string{}
+	if details.Config == nil {
+		return overrides, nil
+	}
+	for key, value := range details.Config.Labels {
+		if imageValue, ok := imageLabels[key]; !ok || imageValue != value {
+			overrides[key] = value
+		}
+	}
+
+	return overrides, nil
+}
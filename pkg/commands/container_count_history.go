@@ -0,0 +1,80 @@
+package commands
+
+import (
+	"time"
+
+	"github.com/sasha-s/go-deadlock"
+)
+
+// maxCountSamples is how many container count samples we keep. At our
+// refresh rate that's a few minutes worth, plenty for a sparkline
+const maxCountSamples = 60
+
+// CountSample is the number of containers we saw in each state at a point in time
+type CountSample struct {
+	Time    time.Time
+	Running int
+	Stopped int
+}
+
+// countHistory is a fixed-size ring buffer of container count samples, oldest
+// samples being overwritten once it fills up. The zero value is ready to use.
+type countHistory struct {
+	mutex deadlock.RWMutex
+	// now is swapped out in tests
+	now func() time.Time
+
+	samples [maxCountSamples]CountSample
+	// next is where the next sample will be written
+	next int
+	full bool
+}
+
+func (h *countHistory) currentTime() time.Time {
+	if h.now == nil {
+		return time.Now()
+	}
+	return h.now()
+}
+
+// record adds a sample for the given containers
+func (h *countHistory) record(containers []*Container) {
+	sample := CountSample{}
+	for _, ctr := range containers {
+		if ctr.Container.State == "running" {
+			sample.Running++
+		} else {
+			sample.Stopped++
+		}
+	}
+
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	sample.Time = h.currentTime()
+	h.samples[h.next] = sample
+	h.next = (h.next + 1) % maxCountSamples
+	if h.next == 0 {
+		h.full = true
+	}
+}
+
+// list returns our samples, oldest first
+func (h *countHistory) list() []CountSample {
+	h.mutex.RLock()
+	defer h.mutex.RUnlock()
+
+	if !h.full {
+		return append([]CountSample{}, h.samples[:h.next]...)
+	}
+
+	result := make([]CountSample, 0, maxCountSamples)
+	result = append(result, h.samples[h.next:]...)
+	return append(result, h.samples[:h.next]...)
+}
+
+// ContainerCountHistory returns how many containers were running and stopped at
+// each of our recent refreshes, oldest first
+func (c *DockerCommand) ContainerCountHistory() []CountSample {
+	return c.countHistory.list()
+}
@@ -0,0 +1,40 @@
+package commands
+
+import (
+	"testing"
+	"time"
+
+	dockerTypes "github.com/docker/docker/api/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDockerCommandContainerCountHistory(t *testing.T) {
+	start := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	now := start
+	dockerCommand := NewDummyDockerCommand()
+	dockerCommand.countHistory.now = func() time.Time { return now }
+
+	assert.Empty(t, dockerCommand.ContainerCountHistory())
+
+	containers := []*Container{
+		{Container: dockerTypes.Container{State: "running"}},
+		{Container: dockerTypes.Container{State: "running"}},
+		{Container: dockerTypes.Container{State: "exited"}},
+	}
+	dockerCommand.countHistory.record(containers)
+	assert.EqualValues(t, []CountSample{{Time: start, Running: 2, Stopped: 1}}, dockerCommand.ContainerCountHistory())
+
+	// overfill the buffer so that the oldest samples get dropped
+	for i := 1; i < maxCountSamples+5; i++ {
+		now = start.Add(time.Duration(i) * time.Second)
+		dockerCommand.countHistory.record(containers[:i%3])
+	}
+
+	history := dockerCommand.ContainerCountHistory()
+	assert.Len(t, history, maxCountSamples)
+	assert.EqualValues(t, start.Add(5*time.Second), history[0].Time)
+	assert.EqualValues(t, now, history[len(history)-1].Time)
+	for i := 1; i < len(history); i++ {
+		assert.True(t, history[i].Time.After(history[i-1].Time))
+	}
+}
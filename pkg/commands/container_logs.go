@@ -0,0 +1,233 @@
+package commands
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/filters"
+	"github.com/docker/docker/pkg/stdcopy"
+	"github.com/go-errors/errors"
+	"github.com/jesseduffield/lazydocker/pkg/utils"
+)
+
+// LogTailOptions determines how we fetch a container's recent logs
+type LogTailOptions struct {
+	// Tail is how many lines to return from the end of the logs, or "all"
+	Tail string
+
+	// Timestamps prefixes each line with the time it was logged
+	Timestamps bool
+
+	// StripANSI removes colour codes from the logs, for when they're being
+	// exported or displayed somewhere that can't render them. The live logs view
+	// keeps them.
+	StripANSI bool
+
+	// SanitizeUTF8 replaces any bytes that aren't valid UTF-8 (e.g. from a
+	// process logging in latin-1, or binary output) with the unicode
+	// replacement character so that they can't garble the display
+	SanitizeUTF8 bool
+}
+
+// GetLogTail returns the most recent logs of the given container. Unlike the
+// logs view in the main panel, this doesn't follow the logs.
+func (c *DockerCommand) GetLogTail(nameOrID string, opts LogTailOptions) (string, error) {
+	ctx := context.Background()
+
+	details, err := c.Client.ContainerInspect(ctx, nameOrID)
+	if err != nil {
+		return "", err
+	}
+
+	readCloser, err := c.Client.ContainerLogs(ctx, nameOrID, container.LogsOptions{
+		ShowStdout: true,
+		ShowStderr: true,
+		Timestamps: opts.Timestamps,
+		Tail:       opts.Tail,
+	})
+	if err != nil {
+		return "", err
+	}
+	defer readCloser.Close()
+
+	var buf bytes.Buffer
+	// containers with a TTY give us the raw stream, otherwise stdout and stderr
+	// are multiplexed together
+	if details.Config != nil && details.Config.Tty {
+		_, err = io.Copy(&buf, readCloser)
+	} else {
+		_, err = stdcopy.StdCopy(&buf, &buf, readCloser)
+	}
+	if err != nil {
+		return "", err
+	}
+
+	logs := buf.String()
+	if opts.StripANSI {
+		logs = utils.StripANSI(logs)
+	}
+	if opts.SanitizeUTF8 {
+		logs = strings.ToValidUTF8(logs, "\uFFFD")
+	}
+
+	return logs, nil
+}
+
+type taggedLogLine struct {
+	loggedAt time.Time
+	text     string
+}
+
+// SaveProjectLogs writes the logs of every container in the given compose
+// project to a single file, merged in the order they were logged and with each
+// line prefixed by the name of the container that logged it. A container whose
+// logs can't be read gets a line saying so instead of failing the whole export.
+func (c *DockerCommand) SaveProjectLogs(project string, path string) error {
+	containers, err := c.Client.ContainerList(context.Background(), container.ListOptions{
+		All:     true,
+		Filters: filters.NewArgs(filters.Arg("label", "com.docker.compose.project="+project)),
+	})
+	if err != nil {
+		return err
+	}
+
+	lines := []taggedLogLine{}
+	for _, ctr := range containers {
+		name := strings.TrimLeft(ctr.Names[0], "/")
+		logs, err := c.GetLogTail(ctr.ID, LogTailOptions{Tail: "all", Timestamps: true, StripANSI: true, SanitizeUTF8: true})
+		if err != nil {
+			// e.g. a logging driver docker can't read back from. We still want
+			// the rest of the project's logs, but we note the gap at the top of
+			// the file so it isn't mistaken for a container that logged nothing
+			c.Log.Error(err)
+			lines = append(lines, taggedLogLine{text: "[" + name + "] couldn't read logs: " + err.Error()})
+			continue
+		}
+
+		for _, line := range utils.SplitLines(logs) {
+			timestamp, text, _ := strings.Cut(line, " ")
+			loggedAt, _ := time.Parse(time.RFC3339Nano, timestamp)
+			lines = append(lines, taggedLogLine{
+				loggedAt: loggedAt,
+				text:     timestamp + " [" + name + "] " + text,
+			})
+		}
+	}
+
+	sort.SliceStable(lines, func(i, j int) bool {
+		return lines[i].loggedAt.Before(lines[j].loggedAt)
+	})
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return WrapError(err)
+	}
+
+	var buf bytes.Buffer
+	for _, line := range lines {
+		buf.WriteString(line.text + "\n")
+	}
+
+	return WrapError(os.WriteFile(path, buf.Bytes(), 0o644))
+}
+
+// GetLogSize returns the size in bytes of the given container's log file. We
+// stat the file on this machine, so this only works for a local daemon using a
+// file-based log driver (json-file or local).
+func (c *DockerCommand) GetLogSize(nameOrID string) (int64, error) {
+	if err := c.requireLocalDaemon("getting log sizes"); err != nil {
+		return 0, err
+	}
+
+	details, err := c.Client.ContainerInspect(context.Background(), nameOrID)
+	if err != nil {
+		return 0, err
+	}
+
+	if details.LogPath == "" {
+		return 0, errors.New("container " + nameOrID + " has no log file")
+	}
+
+	info, err := os.Stat(details.LogPath)
+	if err != nil {
+		return 0, WrapError(err)
+	}
+
+	return info.Size(), nil
+}
+
+// TotalLogSize returns the combined size in bytes of all containers' log files,
+// for spotting when logs are eating the disk. Containers whose log size we
+// can't determine are skipped, but if that's all of them we return an error
+// rather than a misleading zero. Like GetLogSize, this needs a local daemon.
+func (c *DockerCommand) TotalLogSize() (int64, error) {
+	if err := c.requireLocalDaemon("getting log sizes"); err != nil {
+		return 0, err
+	}
+
+	containers, err := c.Client.ContainerList(context.Background(), container.ListOptions{All: true})
+	if err != nil {
+		return 0, err
+	}
+
+	var total int64
+	var firstErr error
+	determined := 0
+	for _, ctr := range containers {
+		size, err := c.GetLogSize(ctr.ID)
+		if err != nil {
+			c.Log.Warn(err)
+			if firstErr == nil {
+				firstErr = err
+			}
+			continue
+		}
+		total += size
+		determined++
+	}
+
+	if len(containers) > 0 && determined == 0 {
+		return 0, errors.Errorf("could not determine the log size of any container: %v", firstErr)
+	}
+
+	return total, nil
+}
+
+// ClearLogs empties the given container's log file, freeing disk space without
+// having to recreate the container. As a safety check we refuse to touch the
+// file unless it's inside docker's data root, and unless the daemon is local,
+// as otherwise the path is on another machine and could be anything here. We
+// also need write access to the file (usually root).
+func (c *DockerCommand) ClearLogs(nameOrID string) error {
+	if err := c.requireLocalDaemon("clearing logs"); err != nil {
+		return err
+	}
+
+	details, err := c.Client.ContainerInspect(context.Background(), nameOrID)
+	if err != nil {
+		return err
+	}
+
+	if details.LogPath == "" {
+		return errors.New("container " + nameOrID + " has no log file")
+	}
+
+	dataRoot, err := c.DataRoot()
+	if err != nil {
+		return err
+	}
+
+	rel, err := filepath.Rel(dataRoot, filepath.Clean(details.LogPath))
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return errors.Errorf("refusing to truncate %s as it is outside docker's data root %s", details.LogPath, dataRoot)
+	}
+
+	c.Log.Warn("truncating log file " + details.LogPath)
+	return WrapError(os.Truncate(details.LogPath, 0))
+}
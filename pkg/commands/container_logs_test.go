@@ -0,0 +1,196 @@
+package commands
+
+import (
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"unicode/utf8"
+
+	dockerTypes "github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/system"
+	"github.com/docker/docker/pkg/stdcopy"
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeContainerHandler serves the given details when the container is inspected
+// and the given lines as its multiplexed stdout, as for a non-TTY container
+func fakeContainerHandler(t *testing.T, mux *http.ServeMux, details dockerTypes.ContainerJSON, lines string) {
+	if details.Config == nil {
+		details.Config = &container.Config{}
+	}
+	id := details.ID
+	mux.HandleFunc(apiPath("/containers/"+id+"/json"), func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(t, w, details)
+	})
+	mux.HandleFunc(apiPath("/containers/"+id+"/logs"), func(w http.ResponseWriter, r *http.Request) {
+		_, err := stdcopy.NewStdWriter(w, stdcopy.Stdout).Write([]byte(lines))
+		assert.NoError(t, err)
+	})
+}
+
+func TestDockerCommandGetLogTail(t *testing.T) {
+	mux := http.NewServeMux()
+	fakeContainerHandler(t, mux, dockerTypes.ContainerJSON{
+		ContainerJSONBase: &dockerTypes.ContainerJSONBase{ID: "abc"},
+	}, "\x1b[32mINFO\x1b[0m started\n\x1b[31mERROR\x1b[0m crashed\n")
+	dockerCommand := newFakeDockerCommand(t, mux)
+
+	logs, err := dockerCommand.GetLogTail("abc", LogTailOptions{Tail: "10"})
+	assert.NoError(t, err)
+	assert.EqualValues(t, "\x1b[32mINFO\x1b[0m started\n\x1b[31mERROR\x1b[0m crashed\n", logs)
+
+	logs, err = dockerCommand.GetLogTail("abc", LogTailOptions{Tail: "10", StripANSI: true})
+	assert.NoError(t, err)
+	assert.EqualValues(t, "INFO started\nERROR crashed\n", logs)
+}
+
+func TestDockerCommandGetLogTailInvalidUTF8(t *testing.T) {
+	mux := http.NewServeMux()
+	fakeContainerHandler(t, mux, dockerTypes.ContainerJSON{
+		ContainerJSONBase: &dockerTypes.ContainerJSONBase{ID: "abc"},
+	}, "caf\xe9 opened\nbinary \xff\xfe\x00 blob\n")
+	dockerCommand := newFakeDockerCommand(t, mux)
+
+	logs, err := dockerCommand.GetLogTail("abc", LogTailOptions{Tail: "10"})
+	assert.NoError(t, err)
+	assert.EqualValues(t, "caf\xe9 opened\nbinary \xff\xfe\x00 blob\n", logs)
+
+	logs, err = dockerCommand.GetLogTail("abc", LogTailOptions{Tail: "10", SanitizeUTF8: true})
+	assert.NoError(t, err)
+	assert.True(t, utf8.ValidString(logs))
+	assert.EqualValues(t, "caf\uFFFD opened\nbinary \uFFFD\x00 blob\n", logs)
+}
+
+func TestDockerCommandSaveProjectLogs(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc(apiPath("/containers/json"), func(w http.ResponseWriter, r *http.Request) {
+		assert.Contains(t, r.URL.Query().Get("filters"), "com.docker.compose.project=shop")
+		writeJSON(t, w, []dockerTypes.Container{
+			{ID: "web", Names: []string{"/shop-web-1"}},
+			{ID: "db", Names: []string{"/shop-db-1"}},
+			{ID: "cache", Names: []string{"/shop-cache-1"}},
+		})
+	})
+	mux.HandleFunc(apiPath("/containers/cache/json"), func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(t, w, dockerTypes.ContainerJSON{ContainerJSONBase: &dockerTypes.ContainerJSONBase{ID: "cache"}, Config: &container.Config{}})
+	})
+	mux.HandleFunc(apiPath("/containers/cache/logs"), func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "configured logging driver does not support reading", http.StatusNotImplemented)
+	})
+	fakeContainerHandler(t, mux, dockerTypes.ContainerJSON{ContainerJSONBase: &dockerTypes.ContainerJSONBase{ID: "web"}},
+		"2024-01-01T12:00:01.000000000Z listening on :80\n2024-01-01T12:00:03.000000000Z \x1b[33mGET /\x1b[0m\n")
+	fakeContainerHandler(t, mux, dockerTypes.ContainerJSON{ContainerJSONBase: &dockerTypes.ContainerJSONBase{ID: "db"}},
+		"2024-01-01T12:00:00.000000000Z starting\n2024-01-01T12:00:02.000000000Z ready\n")
+
+	path := filepath.Join(t.TempDir(), "exports", "shop.log")
+	assert.NoError(t, newFakeDockerCommand(t, mux).SaveProjectLogs("shop", path))
+
+	content, err := os.ReadFile(path)
+	assert.NoError(t, err)
+	assert.EqualValues(t, `[shop-cache-1] couldn't read logs: Error response from daemon: configured logging driver does not support reading
+2024-01-01T12:00:00.000000000Z [shop-db-1] starting
+2024-01-01T12:00:01.000000000Z [shop-web-1] listening on :80
+2024-01-01T12:00:02.000000000Z [shop-db-1] ready
+2024-01-01T12:00:03.000000000Z [shop-web-1] GET /
+`, string(content))
+}
+
+func TestDockerCommandTotalLogSize(t *testing.T) {
+	dir := t.TempDir()
+	logPaths := map[string]string{
+		"web":     filepath.Join(dir, "web-json.log"),
+		"db":      filepath.Join(dir, "db-json.log"),
+		"deleted": filepath.Join(dir, "deleted-json.log"),
+		"syslog":  "",
+	}
+	assert.NoError(t, os.WriteFile(logPaths["web"], make([]byte, 1000), 0o600))
+	assert.NoError(t, os.WriteFile(logPaths["db"], make([]byte, 234), 0o600))
+
+	listed := []dockerTypes.Container{{ID: "web"}, {ID: "db"}, {ID: "deleted"}, {ID: "syslog"}}
+	mux := http.NewServeMux()
+	mux.HandleFunc(apiPath("/containers/json"), func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(t, w, listed)
+	})
+	for id, logPath := range logPaths {
+		details := dockerTypes.ContainerJSON{ContainerJSONBase: &dockerTypes.ContainerJSONBase{ID: id, LogPath: logPath}}
+		mux.HandleFunc(apiPath("/containers/"+id+"/json"), func(w http.ResponseWriter, r *http.Request) {
+			writeJSON(t, w, details)
+		})
+	}
+	dockerCommand := newFakeLocalDockerCommand(t, mux)
+
+	size, err := dockerCommand.GetLogSize("web")
+	assert.NoError(t, err)
+	assert.EqualValues(t, 1000, size)
+
+	_, err = dockerCommand.GetLogSize("syslog")
+	assert.EqualError(t, err, "container syslog has no log file")
+
+	total, err := dockerCommand.TotalLogSize()
+	assert.NoError(t, err)
+	assert.EqualValues(t, 1234, total)
+
+	// when we can't tell any container's log size, we say so rather than
+	// claiming logs take up nothing
+	listed = []dockerTypes.Container{{ID: "syslog"}, {ID: "deleted"}}
+	_, err = dockerCommand.TotalLogSize()
+	assert.EqualError(t, err, "could not determine the log size of any container: container syslog has no log file")
+
+	listed = []dockerTypes.Container{}
+	total, err = dockerCommand.TotalLogSize()
+	assert.NoError(t, err)
+	assert.EqualValues(t, 0, total)
+
+	// a remote daemon's log paths aren't on this machine, even if we happen to
+	// have a file at the same path
+	remoteDockerCommand := newFakeDockerCommand(t, mux)
+	expectedErr := "getting log sizes needs a local docker daemon, but docker is at " + remoteDockerCommand.Client.DaemonHost()
+	_, err = remoteDockerCommand.GetLogSize("web")
+	assert.EqualError(t, err, expectedErr)
+	_, err = remoteDockerCommand.TotalLogSize()
+	assert.EqualError(t, err, expectedErr)
+}
+
+func TestDockerCommandClearLogs(t *testing.T) {
+	dataRoot := t.TempDir()
+	logPath := filepath.Join(dataRoot, "containers", "abc", "abc-json.log")
+	assert.NoError(t, os.MkdirAll(filepath.Dir(logPath), 0o755))
+	assert.NoError(t, os.WriteFile(logPath, []byte("lots of logs\n"), 0o600))
+
+	outsidePath := filepath.Join(t.TempDir(), "precious.log")
+	assert.NoError(t, os.WriteFile(outsidePath, []byte("keep me\n"), 0o600))
+
+	mux := http.NewServeMux()
+	mux.HandleFunc(apiPath("/info"), func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(t, w, system.Info{DockerRootDir: dataRoot})
+	})
+	for id, path := range map[string]string{"abc": logPath, "sneaky": outsidePath} {
+		details := dockerTypes.ContainerJSON{ContainerJSONBase: &dockerTypes.ContainerJSONBase{ID: id, LogPath: path}}
+		mux.HandleFunc(apiPath("/containers/"+id+"/json"), func(w http.ResponseWriter, r *http.Request) {
+			writeJSON(t, w, details)
+		})
+	}
+	dockerCommand := newFakeLocalDockerCommand(t, mux)
+
+	assert.NoError(t, dockerCommand.ClearLogs("abc"))
+	info, err := os.Stat(logPath)
+	assert.NoError(t, err)
+	assert.EqualValues(t, 0, info.Size())
+
+	err = dockerCommand.ClearLogs("sneaky")
+	assert.EqualError(t, err, "refusing to truncate "+outsidePath+" as it is outside docker's data root "+dataRoot)
+	content, err := os.ReadFile(outsidePath)
+	assert.NoError(t, err)
+	assert.EqualValues(t, "keep me\n", string(content))
+
+	// with a remote daemon, the path it gives us means nothing here
+	assert.NoError(t, os.WriteFile(logPath, []byte("remote logs\n"), 0o600))
+	remoteDockerCommand := newFakeDockerCommand(t, mux)
+	err = remoteDockerCommand.ClearLogs("abc")
+	assert.EqualError(t, err, "clearing logs needs a local docker daemon, but docker is at "+remoteDockerCommand.Client.DaemonHost())
+	content, err = os.ReadFile(logPath)
+	assert.NoError(t, err)
+	assert.EqualValues(t, "remote logs\n", string(content))
+}
@@ -1,8 +1,17 @@
 package commands
 
 import (
+	"context"
+	"encoding/json"
 	"math"
+	"sort"
+	"sync"
 	"time"
+
+	dockerTypes "github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/container"
+	"github.com/go-errors/errors"
+	"github.com/samber/lo"
 )
 
 // RecordedStats contains both the container stats we've received from docker, and our own derived stats  from those container stats. When configuring a graph, you're basically specifying the path of a value in this struct
@@ -186,3 +195,122 @@ func (c *Container) GetLastStats() (*RecordedStats, bool) {
 	}
 	return history[len(history)-1], true
 }
+
+// NetworkThroughput returns the container's receive and transmit rates in bytes
+// per second, based on the last two stats samples we've recorded. Returns zero
+// if we don't have two samples yet.
+func (c *Container) NetworkThroughput() (float64, float64, error) {
+	c.StatsMutex.Lock()
+	defer c.StatsMutex.Unlock()
+
+	if len(c.StatHistory) < 2 {
+		return 0, 0, nil
+	}
+
+	previous := c.StatHistory[len(c.StatHistory)-2]
+	latest := c.StatHistory[len(c.StatHistory)-1]
+
+	seconds := latest.RecordedAt.Sub(previous.RecordedAt).Seconds()
+	if seconds <= 0 {
+		return 0, 0, errors.New("stats samples are out of order")
+	}
+
+	rate := func(previousBytes int, latestBytes int) float64 {
+		// counters reset when the container restarts
+		if latestBytes < previousBytes {
+			return 0
+		}
+		return float64(latestBytes-previousBytes) / seconds
+	}
+
+	previousNetwork := previous.ClientStats.Networks.Eth0
+	latestNetwork := latest.ClientStats.Networks.Eth0
+	return rate(previousNetwork.RxBytes, latestNetwork.RxBytes), rate(previousNetwork.TxBytes, latestNetwork.TxBytes), nil
+}
+
+// statsSampleConcurrency is how many containers we'll fetch a stats sample for
+// at once. Each request takes docker about a second because it has to take two
+// CPU readings, so fetching them one after another would be far too slow.
+const statsSampleConcurrency = 4
+
+// containerStatsSample gets a single stats sample for a container. We don't
+// use the one-shot endpoint because we need docker to prime the previous CPU
+// reading in order to work out a CPU percentage.
+func (c *DockerCommand) containerStatsSample(ctx context.Context, id string) (ContainerStats, error) {
+	response, err := c.Client.ContainerStats(ctx, id, false)
+	if err != nil {
+		return ContainerStats{}, err
+	}
+	defer response.Body.Close()
+
+	var stats ContainerStats
+	if err := json.NewDecoder(response.Body).Decode(&stats); err != nil {
+		return ContainerStats{}, err
+	}
+
+	return stats, nil
+}
+
+// containerStatsSamples gets a single stats sample for each of the given
+// containers, statsSampleConcurrency at a time. The results line up with ids,
+// with errs[i] set if we couldn't get a sample for ids[i].
+func (c *DockerCommand) containerStatsSamples(ctx context.Context, ids []string) ([]ContainerStats, []error) {
+	samples := make([]ContainerStats, len(ids))
+	errs := make([]error, len(ids))
+
+	semaphore := make(chan struct{}, statsSampleConcurrency)
+	var wg sync.WaitGroup
+	for i, id := range ids {
+		wg.Add(1)
+		go func(i int, id string) {
+			defer wg.Done()
+			semaphore <- struct{}{}
+			defer func() { <-semaphore }()
+
+			samples[i], errs[i] = c.containerStatsSample(ctx, id)
+		}(i, id)
+	}
+	wg.Wait()
+
+	return samples, errs
+}
+
+// ContainersByPressure returns the running containers sorted by how hard they
+// are working, hottest first. A container's pressure is its CPU percentage
+// plus its memory percentage. Containers we can't get stats for (e.g. because
+// they stopped in the meantime) are left out.
+func (c *DockerCommand) ContainersByPressure(ctx context.Context) ([]*Container, error) {
+	containers, err := c.Client.ContainerList(ctx, container.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	ids := lo.Map(containers, func(ctr dockerTypes.Container, _ int) string { return ctr.ID })
+	samples, errs := c.containerStatsSamples(ctx, ids)
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	type scoredContainer struct {
+		container *Container
+		score     float64
+	}
+	scored := []scoredContainer{}
+	for i, ctr := range containers {
+		if errs[i] != nil {
+			c.Log.Warn(errs[i])
+			continue
+		}
+		newContainer := c.newContainer(ctr.ID)
+		setContainerSummary(newContainer, ctr)
+		score := samples[i].CalculateContainerCPUPercentage() + samples[i].CalculateContainerMemoryUsage()
+		scored = append(scored, scoredContainer{container: newContainer, score: score})
+	}
+
+	sort.SliceStable(scored, func(i, j int) bool {
+		return scored[i].score > scored[j].score
+	})
+
+	return lo.Map(scored, func(s scoredContainer, _ int) *Container { return s.container }), nil
+}
@@ -1,8 +1,15 @@
 package commands
 
 import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
 	"testing"
+	"time"
 
+	dockerTypes "github.com/docker/docker/api/types"
+	"github.com/samber/lo"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -15,3 +22,106 @@ func TestCalculateContainerCPUPercentage(t *testing.T) {
 
 	assert.EqualValues(t, 62.5, container.CalculateContainerCPUPercentage())
 }
+
+func TestContainerNetworkThroughput(t *testing.T) {
+	sample := func(recordedAt time.Time, rxBytes int, txBytes int) *RecordedStats {
+		stats := &RecordedStats{RecordedAt: recordedAt}
+		stats.ClientStats.Networks.Eth0.RxBytes = rxBytes
+		stats.ClientStats.Networks.Eth0.TxBytes = txBytes
+		return stats
+	}
+	start := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	ctr := &Container{}
+	rx, tx, err := ctr.NetworkThroughput()
+	assert.NoError(t, err)
+	assert.EqualValues(t, 0, rx)
+	assert.EqualValues(t, 0, tx)
+
+	ctr.StatHistory = []*RecordedStats{sample(start, 1000, 500)}
+	rx, tx, err = ctr.NetworkThroughput()
+	assert.NoError(t, err)
+	assert.EqualValues(t, 0, rx)
+	assert.EqualValues(t, 0, tx)
+
+	ctr.StatHistory = append(ctr.StatHistory, sample(start.Add(2*time.Second), 5000, 1500))
+	rx, tx, err = ctr.NetworkThroughput()
+	assert.NoError(t, err)
+	assert.EqualValues(t, 2000, rx)
+	assert.EqualValues(t, 500, tx)
+
+	ctr.StatHistory = append(ctr.StatHistory, sample(start.Add(4*time.Second), 100, 2500))
+	rx, tx, err = ctr.NetworkThroughput()
+	assert.NoError(t, err)
+	assert.EqualValues(t, 0, rx)
+	assert.EqualValues(t, 500, tx)
+}
+
+func TestDockerCommandContainersByPressure(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc(apiPath("/containers/json"), func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(t, w, []dockerTypes.Container{
+			{ID: "idle", Names: []string{"/idle"}},
+			{ID: "hot", Names: []string{"/hot"}},
+			{ID: "gone", Names: []string{"/gone"}},
+			{ID: "hungry", Names: []string{"/hungry"}},
+		})
+	})
+	// cpu percentages are (total usage delta * 100) / 1000 and memory
+	// percentages are usage out of a limit of 100
+	stats := map[string][2]int{
+		"idle":   {10, 10},
+		"hot":    {500, 50},
+		"hungry": {0, 70},
+	}
+	for id, s := range stats {
+		body := fmt.Sprintf(`{"cpu_stats":{"cpu_usage":{"total_usage":%d},"system_cpu_usage":1000},"memory_stats":{"usage":%d,"limit":100}}`, s[0], s[1])
+		mux.HandleFunc(apiPath("/containers/"+id+"/stats"), func(w http.ResponseWriter, r *http.Request) {
+			_, _ = w.Write([]byte(body))
+		})
+	}
+	mux.HandleFunc(apiPath("/containers/gone/stats"), func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	})
+	dockerCommand := newFakeDockerCommand(t, mux)
+
+	containers, err := dockerCommand.ContainersByPressure(context.Background())
+	assert.NoError(t, err)
+	assert.EqualValues(t, []string{"hot", "hungry", "idle"}, lo.Map(containers, func(ctr *Container, _ int) string { return ctr.Name }))
+}
+
+func TestDockerCommandContainerStatsSamplesConcurrency(t *testing.T) {
+	var mutex sync.Mutex
+	inFlight, maxInFlight := 0, 0
+
+	ids := []string{}
+	mux := http.NewServeMux()
+	for i := 0; i < 3*statsSampleConcurrency; i++ {
+		id := fmt.Sprintf("ctr%d", i)
+		ids = append(ids, id)
+		mux.HandleFunc(apiPath("/containers/"+id+"/stats"), func(w http.ResponseWriter, r *http.Request) {
+			mutex.Lock()
+			inFlight++
+			maxInFlight = max(maxInFlight, inFlight)
+			mutex.Unlock()
+
+			time.Sleep(20 * time.Millisecond)
+
+			mutex.Lock()
+			inFlight--
+			mutex.Unlock()
+			_, _ = w.Write([]byte(`{"memory_stats":{"usage":1}}`))
+		})
+	}
+	ids = append(ids, "gone")
+	dockerCommand := newFakeDockerCommand(t, mux)
+
+	samples, errs := dockerCommand.containerStatsSamples(context.Background(), ids)
+	assert.Len(t, samples, len(ids))
+	for i := range ids[:len(ids)-1] {
+		assert.NoError(t, errs[i])
+		assert.EqualValues(t, 1, samples[i].MemoryStats.Usage)
+	}
+	assert.Error(t, errs[len(ids)-1])
+	assert.EqualValues(t, statsSampleConcurrency, maxInFlight)
+}
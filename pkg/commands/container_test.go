@@ -0,0 +1,682 @@
+package commands
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	dockerTypes "github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/filters"
+	"github.com/docker/docker/api/types/image"
+	"github.com/docker/docker/api/types/mount"
+	"github.com/docker/docker/client"
+	"github.com/go-errors/errors"
+	"github.com/samber/lo"
+	"github.com/stretchr/testify/assert"
+)
+
+func newDetailedContainer(hostConfig *container.HostConfig) *Container {
+	return &Container{
+		Details: dockerTypes.ContainerJSON{
+			ContainerJSONBase: &dockerTypes.ContainerJSONBase{
+				HostConfig: hostConfig,
+			},
+			Config: &container.Config{},
+		},
+	}
+}
+
+func TestContainerCgroupInfo(t *testing.T) {
+	ctr := newDetailedContainer(&container.HostConfig{
+		CgroupnsMode: container.CgroupnsModeHost,
+		Resources:    container.Resources{CgroupParent: "/lazydocker.slice"},
+	})
+	assert.EqualValues(t, "/lazydocker.slice", ctr.CgroupParent())
+	assert.EqualValues(t, "host", ctr.CgroupNamespaceMode())
+
+	ctr = newDetailedContainer(&container.HostConfig{})
+	assert.EqualValues(t, "", ctr.CgroupParent())
+	assert.EqualValues(t, "private", ctr.CgroupNamespaceMode())
+
+	assert.EqualValues(t, "private", (&Container{}).CgroupNamespaceMode())
+}
+
+func TestDockerCommandRunAndCapture(t *testing.T) {
+	type scenario struct {
+		command func(string, ...string) *exec.Cmd
+		test    func(string, int, error)
+	}
+
+	scenarios := []scenario{
+		{
+			func(name string, args ...string) *exec.Cmd {
+				assert.EqualValues(t, "docker", name)
+				assert.EqualValues(t, []string{"run", "--rm", "alpine", "echo", "hello world"}, args)
+				return exec.Command("echo", "hello world")
+			},
+			func(output string, exitCode int, err error) {
+				assert.NoError(t, err)
+				assert.EqualValues(t, "hello world\n", output)
+				assert.EqualValues(t, 0, exitCode)
+			},
+		},
+		{
+			func(name string, args ...string) *exec.Cmd {
+				return exec.Command("sh", "-c", "echo partial; exit 3")
+			},
+			func(output string, exitCode int, err error) {
+				assert.NoError(t, err)
+				assert.EqualValues(t, "partial\n", output)
+				assert.EqualValues(t, 3, exitCode)
+			},
+		},
+		{
+			func(name string, args ...string) *exec.Cmd {
+				return exec.Command("sh", "-c", "echo 'Unable to find image' >&2; exit 125")
+			},
+			func(output string, exitCode int, err error) {
+				assert.EqualError(t, err, "Unable to find image\n")
+				assert.EqualValues(t, -1, exitCode)
+			},
+		},
+	}
+
+	for _, s := range scenarios {
+		osCommand := NewDummyOSCommand()
+		osCommand.SetCommand(s.command)
+		s.test(NewDummyDockerCommandWithOSCommand(osCommand).RunAndCapture("alpine", []string{"echo", "hello world"}))
+	}
+
+	// the image goes straight into docker's args, so it mustn't be able to
+	// pass for a flag
+	osCommand := NewDummyOSCommand()
+	osCommand.SetCommand(func(name string, args ...string) *exec.Cmd {
+		t.Errorf("unexpected command %s %v", name, args)
+		return exec.Command("true")
+	})
+	_, _, err := NewDummyDockerCommandWithOSCommand(osCommand).RunAndCapture("--privileged", []string{"sh"})
+	assert.EqualError(t, err, `invalid image reference "--privileged": invalid reference format`)
+}
+
+func TestDockerCommandRecentFailures(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc(apiPath("/containers/json"), func(w http.ResponseWriter, r *http.Request) {
+		args, err := filters.FromJSON(r.URL.Query().Get("filters"))
+		assert.NoError(t, err)
+		assert.EqualValues(t, []string{"exited"}, args.Get("status"))
+		if args.Contains("exited") {
+			writeJSON(t, w, []dockerTypes.Container{{ID: "ok", Created: 4}})
+			return
+		}
+		writeJSON(t, w, []dockerTypes.Container{{ID: "ok", Created: 4}, {ID: "old", Created: 1}, {ID: "new", Created: 2}, {ID: "middle", Created: 3}})
+	})
+
+	exited := func(id string, exitCode int, finishedAt string) dockerTypes.ContainerJSON {
+		return dockerTypes.ContainerJSON{
+			ContainerJSONBase: &dockerTypes.ContainerJSONBase{
+				ID:    id,
+				Name:  "/" + id,
+				State: &dockerTypes.ContainerState{ExitCode: exitCode, FinishedAt: finishedAt},
+			},
+		}
+	}
+	// we shouldn't be inspecting successes, or failures older than the limit
+	for _, id := range []string{"ok", "old"} {
+		mux.HandleFunc(apiPath("/containers/"+id+"/json"), func(w http.ResponseWriter, r *http.Request) {
+			t.Errorf("unexpected inspect of %s", r.URL.Path)
+		})
+	}
+	fakeContainerHandler(t, mux, exited("new", 137, "2024-01-01T11:00:00Z"), "starting\n\x1b[31mkilled\x1b[0m\n")
+	fakeContainerHandler(t, mux, exited("middle", 2, "2024-01-01T10:00:00Z"), "bad config\n")
+
+	failures, err := newFakeDockerCommand(t, mux).RecentFailures(2)
+	assert.NoError(t, err)
+	assert.EqualValues(t, []FailureInfo{
+		{
+			ContainerID: "new",
+			Name:        "new",
+			ExitCode:    137,
+			FinishedAt:  time.Date(2024, 1, 1, 11, 0, 0, 0, time.UTC),
+			LastLines:   []string{"starting", "killed"},
+		},
+		{
+			ContainerID: "middle",
+			Name:        "middle",
+			ExitCode:    2,
+			FinishedAt:  time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC),
+			LastLines:   []string{"bad config"},
+		},
+	}, failures)
+}
+
+func TestDockerCommandRunForegroundCmd(t *testing.T) {
+	cmd, err := NewDummyDockerCommand().RunForegroundCmd("alpine:latest", []string{"sh", "-c", "echo hello; exit 1"})
+	assert.NoError(t, err)
+	assert.EqualValues(t, []string{"docker", "run", "--rm", "-it", "alpine:latest", "sh", "-c", "echo hello; exit 1"}, cmd.Args)
+
+	cmd, err = NewDummyDockerCommand().RunForegroundCmd("alpine:latest", nil)
+	assert.NoError(t, err)
+	assert.EqualValues(t, []string{"docker", "run", "--rm", "-it", "alpine:latest"}, cmd.Args)
+
+	_, err = NewDummyDockerCommand().RunForegroundCmd("-v=/:/host", nil)
+	assert.EqualError(t, err, `invalid image reference "-v=/:/host": invalid reference format`)
+}
+
+func TestContainerShmSizeAndTmpfsMounts(t *testing.T) {
+	ctr := newDetailedContainer(&container.HostConfig{
+		ShmSize: 64 * 1024 * 1024,
+		Tmpfs:   map[string]string{"/run": "size=16m", "/tmp": ""},
+	})
+	assert.EqualValues(t, "64.00MiB", ctr.ShmSize())
+	assert.EqualValues(t, map[string]string{"/run": "size=16m", "/tmp": ""}, ctr.TmpfsMounts())
+
+	ctr = newDetailedContainer(&container.HostConfig{})
+	assert.EqualValues(t, "", ctr.ShmSize())
+	assert.EqualValues(t, map[string]string{}, ctr.TmpfsMounts())
+}
+
+func TestDockerCommandStreamTop(t *testing.T) {
+	polls := 0
+	mux := http.NewServeMux()
+	mux.HandleFunc(apiPath("/containers/abc/top"), func(w http.ResponseWriter, r *http.Request) {
+		polls++
+		processes := [][]string{{"1", "nginx: master process"}}
+		if polls > 1 {
+			processes = append(processes, []string{"7", "nginx: worker process"})
+		}
+		writeJSON(t, w, container.ContainerTopOKBody{Titles: []string{"PID", "CMD"}, Processes: processes})
+	})
+	dockerCommand := newFakeDockerCommand(t, mux)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	results, err := dockerCommand.StreamTop(ctx, "abc", time.Millisecond)
+	assert.NoError(t, err)
+
+	first := <-results
+	assert.EqualValues(t, []string{"PID", "CMD"}, first.Titles)
+	assert.Len(t, first.Processes, 1)
+
+	second := <-results
+	assert.Len(t, second.Processes, 2)
+
+	cancel()
+	for range results {
+		// drain anything sent before the cancel was noticed
+	}
+
+	_, err = dockerCommand.StreamTop(context.Background(), "missing", time.Millisecond)
+	assert.Error(t, err)
+}
+
+func TestContainerStop(t *testing.T) {
+	containerTimeout := 30
+	configTimeout := 5
+
+	configured := newDetailedContainer(&container.HostConfig{})
+	configured.ID = "configured"
+	configured.Details.Config.StopTimeout = &containerTimeout
+
+	unconfigured := newDetailedContainer(&container.HostConfig{})
+	unconfigured.ID = "unconfigured"
+
+	type scenario struct {
+		testName       string
+		ctr            *Container
+		defaultTimeout *int
+		expected       string
+	}
+
+	scenarios := []scenario{
+		{"container's stop timeout overrides the config default", configured, &configTimeout, "30"},
+		{"container's stop timeout is used without a config default", configured, nil, "30"},
+		{"config default is used when the container has no stop timeout", unconfigured, &configTimeout, "5"},
+		{"daemon default is used when neither is set", unconfigured, nil, ""},
+	}
+
+	for _, s := range scenarios {
+		t.Run(s.testName, func(t *testing.T) {
+			var timeout *string
+			mux := http.NewServeMux()
+			mux.HandleFunc(apiPath("/containers/"+s.ctr.ID+"/stop"), func(w http.ResponseWriter, r *http.Request) {
+				t := r.URL.Query().Get("t")
+				timeout = &t
+				w.WriteHeader(http.StatusNoContent)
+			})
+			dockerCommand := newFakeDockerCommand(t, mux)
+			s.ctr.Client = dockerCommand.Client
+			s.ctr.Log = dockerCommand.Log
+
+			assert.NoError(t, s.ctr.Stop(s.defaultTimeout))
+			if assert.NotNil(t, timeout) {
+				assert.EqualValues(t, s.expected, *timeout)
+			}
+		})
+	}
+}
+
+func TestDockerCommandExportInspect(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc(apiPath("/containers/abc/json"), func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"Id":"abc","Name":"/web","FieldWeDontParse":{"nested":[1,2]}}`))
+	})
+
+	output, err := newFakeDockerCommand(t, mux).ExportInspect("abc")
+	assert.NoError(t, err)
+	assert.True(t, json.Valid(output))
+	assert.EqualValues(t, `{
+  "Id": "abc",
+  "Name": "/web",
+  "FieldWeDontParse": {
+    "nested": [
+      1,
+      2
+    ]
+  }
+}`, string(output))
+}
+
+func TestContainerDevices(t *testing.T) {
+	devices := []container.DeviceMapping{
+		{PathOnHost: "/dev/snd", PathInContainer: "/dev/snd", CgroupPermissions: "rwm"},
+		{PathOnHost: "/dev/ttyUSB0", PathInContainer: "/dev/serial", CgroupPermissions: "rw"},
+	}
+	ctr := newDetailedContainer(&container.HostConfig{Resources: container.Resources{Devices: devices}})
+	assert.EqualValues(t, devices, ctr.Devices())
+
+	ctr = newDetailedContainer(&container.HostConfig{})
+	assert.EqualValues(t, []container.DeviceMapping{}, ctr.Devices())
+}
+
+func TestContainerMemorySwap(t *testing.T) {
+	swappiness := int64(10)
+	ctr := newDetailedContainer(&container.HostConfig{Resources: container.Resources{
+		Memory:           512 * 1024 * 1024,
+		MemorySwap:       2 * 1024 * 1024 * 1024,
+		MemorySwappiness: &swappiness,
+	}})
+	assert.EqualValues(t, "2.00GiB", ctr.MemorySwap())
+	assert.EqualValues(t, "10", ctr.MemorySwappiness())
+
+	ctr = newDetailedContainer(&container.HostConfig{Resources: container.Resources{MemorySwap: -1}})
+	assert.EqualValues(t, "unlimited", ctr.MemorySwap())
+
+	ctr = newDetailedContainer(&container.HostConfig{})
+	assert.EqualValues(t, "default", ctr.MemorySwap())
+	assert.EqualValues(t, "default", ctr.MemorySwappiness())
+}
+
+func TestContainerMounts(t *testing.T) {
+	existing := t.TempDir()
+	missing := filepath.Join(t.TempDir(), "deleted")
+
+	newContainer := func(host string) *Container {
+		dockerClient, err := client.NewClientWithOpts(client.WithHost(host))
+		assert.NoError(t, err)
+
+		ctr := newDetailedContainer(&container.HostConfig{})
+		ctr.Client = dockerClient
+		ctr.Details.Mounts = []dockerTypes.MountPoint{
+			{Type: mount.TypeBind, Source: existing, Destination: "/app"},
+			{Type: mount.TypeBind, Source: missing, Destination: "/data"},
+			{Type: mount.TypeVolume, Name: "cache", Source: missing, Destination: "/cache"},
+		}
+		return ctr
+	}
+
+	mounts := newContainer("unix:///var/run/docker.sock").Mounts()
+	assert.Len(t, mounts, 3)
+	assert.False(t, mounts[0].SourceMissing)
+	assert.True(t, mounts[1].SourceMissing)
+	// only bind sources live on the host, so we don't check volumes
+	assert.False(t, mounts[2].SourceMissing)
+	assert.EqualValues(t, "/data", mounts[1].Destination)
+
+	// a remote daemon's bind sources aren't on our filesystem, so we can't tell
+	mounts = newContainer("tcp://10.0.0.5:2375").Mounts()
+	assert.Len(t, mounts, 3)
+	for _, m := range mounts {
+		assert.False(t, m.SourceMissing)
+	}
+
+	assert.True(t, daemonIsLocal("unix:///var/run/docker.sock"))
+	assert.False(t, daemonIsLocal("ssh://me@build-box"))
+	assert.False(t, daemonIsLocal("npipe:////./pipe/docker_engine"))
+}
+
+func TestContainerCapabilitySummary(t *testing.T) {
+	type scenario struct {
+		name     string
+		capAdd   []string
+		capDrop  []string
+		expected string
+	}
+
+	scenarios := []scenario{
+		{"defaults", nil, nil, "default"},
+		{"added", []string{"NET_ADMIN", "SYS_PTRACE"}, nil, "+NET_ADMIN +SYS_PTRACE"},
+		{"dropped", nil, []string{"MKNOD"}, "-MKNOD"},
+		{"both", []string{"CAP_NET_ADMIN"}, []string{"cap_mknod", "NET_RAW"}, "+NET_ADMIN -MKNOD -NET_RAW"},
+		{"drop all", []string{"CHOWN"}, []string{"ALL"}, "+CHOWN -ALL"},
+	}
+
+	for _, s := range scenarios {
+		t.Run(s.name, func(t *testing.T) {
+			ctr := newDetailedContainer(&container.HostConfig{CapAdd: s.capAdd, CapDrop: s.capDrop})
+			assert.EqualValues(t, s.expected, ctr.CapabilitySummary())
+		})
+	}
+}
+
+func TestContainerConsoleSize(t *testing.T) {
+	ctr := newDetailedContainer(&container.HostConfig{ConsoleSize: [2]uint{40, 120}})
+	height, width := ctr.ConsoleSize()
+	assert.EqualValues(t, 40, height)
+	assert.EqualValues(t, 120, width)
+
+	ctr = newDetailedContainer(&container.HostConfig{})
+	height, width = ctr.ConsoleSize()
+	assert.EqualValues(t, 0, height)
+	assert.EqualValues(t, 0, width)
+}
+
+func TestExecResizeOptions(t *testing.T) {
+	terminalSize := func() (int, int, error) { return 200, 50, nil }
+
+	options, err := execResizeOptions(40, 120, terminalSize)
+	assert.NoError(t, err)
+	assert.EqualValues(t, container.ResizeOptions{Height: 40, Width: 120}, options)
+
+	// without a configured console size we match our own terminal
+	options, err = execResizeOptions(0, 0, terminalSize)
+	assert.NoError(t, err)
+	assert.EqualValues(t, container.ResizeOptions{Height: 50, Width: 200}, options)
+
+	_, err = execResizeOptions(0, 0, func() (int, int, error) { return 0, 0, errors.New("not a terminal") })
+	assert.EqualError(t, err, "not a terminal")
+}
+
+func TestDockerCommandResizeExecTTY(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc(apiPath("/exec/exec123/resize"), func(w http.ResponseWriter, r *http.Request) {
+		assert.EqualValues(t, "40", r.URL.Query().Get("h"))
+		assert.EqualValues(t, "120", r.URL.Query().Get("w"))
+	})
+	dockerCommand := newFakeDockerCommand(t, mux)
+
+	ctr := newDetailedContainer(&container.HostConfig{ConsoleSize: [2]uint{40, 120}})
+	assert.NoError(t, dockerCommand.ResizeExecTTY(ctr, "exec123"))
+}
+
+func TestContainerPortURLs(t *testing.T) {
+	ctr := &Container{Container: dockerTypes.Container{Ports: []dockerTypes.Port{
+		{PrivatePort: 53, PublicPort: 5353, IP: "0.0.0.0", Type: "udp"},
+		{PrivatePort: 80, PublicPort: 8080, IP: "0.0.0.0", Type: "tcp"},
+		{PrivatePort: 9000, Type: "tcp"},
+		{PrivatePort: 443, PublicPort: 8443, IP: "127.0.0.1"},
+		{PrivatePort: 80, PublicPort: 8081, IP: "::1", Type: "tcp"},
+		{PrivatePort: 3868, PublicPort: 3868, IP: "0.0.0.0", Type: "sctp"},
+	}}}
+
+	assert.EqualValues(t, []string{"http://localhost:8080/", "http://127.0.0.1:8443/", "http://[::1]:8081/"}, ctr.PortURLs())
+	assert.EqualValues(t, "udp", PortProtocol(ctr.Container.Ports[0]))
+	assert.EqualValues(t, "tcp", PortProtocol(ctr.Container.Ports[3]))
+}
+
+func TestContainerExtraHosts(t *testing.T) {
+	ctr := newDetailedContainer(&container.HostConfig{ExtraHosts: []string{"db.local:10.0.0.5", "host.docker.internal:host-gateway"}})
+	assert.EqualValues(t, []string{"db.local:10.0.0.5", "host.docker.internal:host-gateway"}, ctr.ExtraHosts())
+
+	ctr = newDetailedContainer(&container.HostConfig{})
+	assert.EqualValues(t, []string{}, ctr.ExtraHosts())
+}
+
+func TestDockerCommandCanExec(t *testing.T) {
+	mux := http.NewServeMux()
+	inspect := func(id string, state dockerTypes.ContainerState) {
+		mux.HandleFunc(apiPath("/containers/"+id+"/json"), func(w http.ResponseWriter, r *http.Request) {
+			writeJSON(t, w, dockerTypes.ContainerJSON{
+				ContainerJSONBase: &dockerTypes.ContainerJSONBase{ID: id, State: &state},
+			})
+		})
+	}
+	inspect("running", dockerTypes.ContainerState{Running: true})
+	inspect("stopped", dockerTypes.ContainerState{Running: false})
+	inspect("paused", dockerTypes.ContainerState{Running: true, Paused: true})
+	inspect("distroless", dockerTypes.ContainerState{Running: true})
+
+	mux.HandleFunc(apiPath("/containers/running/archive"), func(w http.ResponseWriter, r *http.Request) {
+		assert.EqualValues(t, "/bin/sh", r.URL.Query().Get("path"))
+		stat, _ := json.Marshal(container.PathStat{Name: "sh"})
+		w.Header().Set("X-Docker-Container-Path-Stat", base64.StdEncoding.EncodeToString(stat))
+	})
+	mux.HandleFunc(apiPath("/containers/distroless/archive"), func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	})
+	dockerCommand := newFakeDockerCommand(t, mux)
+
+	type scenario struct {
+		id             string
+		expectedCanRun bool
+		expectedReason string
+	}
+
+	scenarios := []scenario{
+		{"running", true, ""},
+		{"stopped", false, "container is not running"},
+		{"paused", false, "container is paused"},
+		{"distroless", false, "container has no /bin/sh"},
+	}
+
+	for _, s := range scenarios {
+		canExec, reason, err := dockerCommand.CanExec(s.id)
+		assert.NoError(t, err)
+		assert.EqualValues(t, s.expectedCanRun, canExec, s.id)
+		assert.EqualValues(t, s.expectedReason, reason, s.id)
+	}
+}
+
+func TestContainerEntrypointOverridden(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc(apiPath("/images/sha256:abc/json"), func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(t, w, dockerTypes.ImageInspect{
+			ID:     "sha256:abc",
+			Config: &container.Config{Entrypoint: []string{"/docker-entrypoint.sh"}},
+		})
+	})
+	dockerCommand := newFakeDockerCommand(t, mux)
+
+	type scenario struct {
+		name       string
+		entrypoint []string
+		expected   bool
+	}
+
+	scenarios := []scenario{
+		{"image default", []string{"/docker-entrypoint.sh"}, false},
+		{"overridden", []string{"/bin/sh", "-c"}, true},
+		{"cleared", nil, true},
+	}
+
+	for _, s := range scenarios {
+		t.Run(s.name, func(t *testing.T) {
+			ctr := dockerCommand.newContainer("abc")
+			ctr.Details = dockerTypes.ContainerJSON{
+				ContainerJSONBase: &dockerTypes.ContainerJSONBase{Image: "sha256:abc"},
+				Config:            &container.Config{Entrypoint: s.entrypoint},
+			}
+
+			overridden, err := ctr.EntrypointOverridden()
+			assert.NoError(t, err)
+			assert.EqualValues(t, s.expected, overridden)
+		})
+	}
+}
+
+func TestDockerCommandOrphanedContainers(t *testing.T) {
+	compose := func(project string, service string, dependsOn string) map[string]string {
+		return map[string]string{
+			"com.docker.compose.project":    project,
+			"com.docker.compose.service":    service,
+			"com.docker.compose.depends_on": dependsOn,
+		}
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc(apiPath("/containers/json"), func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(t, w, []dockerTypes.Container{
+			{ID: "web", Names: []string{"/web"}, ImageID: "sha256:web", Labels: compose("shop", "web", "db:service_started:false")},
+			{ID: "db", Names: []string{"/db"}, ImageID: "sha256:db", Labels: compose("shop", "db", "")},
+			{ID: "single", Names: []string{"/single"}, ImageID: "sha256:web", Labels: compose("blog", "app", "")},
+			{ID: "leftover", Names: []string{"/leftover"}, ImageID: "sha256:web", Labels: compose("old", "api", "postgres:service_healthy:true")},
+			{ID: "standalone", Names: []string{"/standalone"}, ImageID: "sha256:web"},
+			{ID: "dangling", Names: []string{"/dangling"}, ImageID: "sha256:gone"},
+		})
+	})
+	mux.HandleFunc(apiPath("/images/json"), func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(t, w, []image.Summary{{ID: "sha256:web"}, {ID: "sha256:db"}})
+	})
+	dockerCommand := newFakeDockerCommand(t, mux)
+
+	orphans, err := dockerCommand.OrphanedContainers()
+	assert.NoError(t, err)
+	assert.EqualValues(t, []string{"leftover", "dangling"}, lo.Map(orphans, func(ctr *Container, _ int) string { return ctr.ID }))
+}
+
+func TestComposeProjectGone(t *testing.T) {
+	existing := filepath.Join(t.TempDir(), "docker-compose.yml")
+	assert.NoError(t, os.WriteFile(existing, []byte("services: {}\n"), 0o600))
+	deleted := filepath.Join(t.TempDir(), "docker-compose.yml")
+
+	labels := func(configFiles string) map[string]string {
+		return map[string]string{
+			"com.docker.compose.project":              "blog",
+			"com.docker.compose.service":              "app",
+			"com.docker.compose.project.config_files": configFiles,
+		}
+	}
+	services := composeProjectServices{"blog": {"app": true}}
+
+	assert.False(t, composeProjectGone(labels(existing), services, true))
+	assert.False(t, composeProjectGone(labels(deleted+","+existing), services, true))
+	assert.True(t, composeProjectGone(labels(deleted), services, true))
+	// without a local daemon we can't look for the files so we assume they exist
+	assert.False(t, composeProjectGone(labels(deleted), services, false))
+	assert.False(t, composeProjectGone(map[string]string{}, services, true))
+}
+
+func TestDockerCommandRestartContainers(t *testing.T) {
+	defer func(delay time.Duration) { restartOrderDelay = delay }(restartOrderDelay)
+	restartOrderDelay = time.Millisecond
+
+	var mutex sync.Mutex
+	restarted := []string{}
+	mux := http.NewServeMux()
+	for _, id := range []string{"db", "cache", "web"} {
+		id := id
+		mux.HandleFunc(apiPath("/containers/"+id+"/restart"), func(w http.ResponseWriter, r *http.Request) {
+			mutex.Lock()
+			defer mutex.Unlock()
+			restarted = append(restarted, id)
+			w.WriteHeader(http.StatusNoContent)
+		})
+	}
+	dockerCommand := newFakeDockerCommand(t, mux)
+
+	results, err := dockerCommand.RestartContainers([]string{"db", "gone", "cache", "web"}, true)
+	assert.EqualError(t, err, "gone: Error response from daemon: 404 page not found")
+	assert.EqualValues(t, []string{"db", "cache", "web"}, restarted)
+	assert.EqualValues(t, []string{"db", "gone", "cache", "web"}, lo.Map(results, func(result RestartResult, _ int) string { return result.ID }))
+	assert.NoError(t, results[0].Err)
+	assert.Error(t, results[1].Err)
+	assert.NoError(t, results[2].Err)
+	assert.NoError(t, results[3].Err)
+
+	restarted = []string{}
+	results, err = dockerCommand.RestartContainers([]string{"web", "db"}, false)
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, []string{"web", "db"}, restarted)
+	assert.EqualValues(t, []RestartResult{{ID: "web"}, {ID: "db"}}, results)
+}
+
+func TestDockerCommandContainerLabelOverrides(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc(apiPath("/containers/web/json"), func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(t, w, dockerTypes.ContainerJSON{
+			ContainerJSONBase: &dockerTypes.ContainerJSONBase{ID: "web", Image: "sha256:abc"},
+			Config: &container.Config{Labels: map[string]string{
+				"maintainer":                 "nginx team",
+				"org.opencontainers.version": "2.0",
+				"com.docker.compose.project": "shop",
+				"team":                       "payments",
+			}},
+		})
+	})
+	mux.HandleFunc(apiPath("/images/sha256:abc/json"), func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(t, w, dockerTypes.ImageInspect{
+			ID: "sha256:abc",
+			Config: &container.Config{Labels: map[string]string{
+				"maintainer":                 "nginx team",
+				"org.opencontainers.version": "1.0",
+			}},
+		})
+	})
+	dockerCommand := newFakeDockerCommand(t, mux)
+
+	overrides, err := dockerCommand.ContainerLabelOverrides("web")
+	assert.NoError(t, err)
+	assert.EqualValues(t, map[string]string{
+		"org.opencontainers.version": "2.0",
+		"com.docker.compose.project": "shop",
+		"team":                       "payments",
+	}, overrides)
+}
+
+func TestDockerCommandContainersByExitClass(t *testing.T) {
+	exitCodes := map[string]int{"migrate": 0, "worker": 1, "oom": 137, "stopped": 143, "segfault": 139}
+	mux := http.NewServeMux()
+	mux.HandleFunc(apiPath("/containers/json"), func(w http.ResponseWriter, r *http.Request) {
+		args, err := filters.FromJSON(r.URL.Query().Get("filters"))
+		assert.NoError(t, err)
+		assert.EqualValues(t, []string{"exited"}, args.Get("status"))
+
+		// mimic docker's exited filter, which matches any of the given codes
+		containers := []dockerTypes.Container{}
+		for _, id := range []string{"migrate", "worker", "oom", "stopped", "segfault"} {
+			if args.Len() > 1 && !args.ExactMatch("exited", strconv.Itoa(exitCodes[id])) {
+				continue
+			}
+			containers = append(containers, dockerTypes.Container{ID: id, Names: []string{"/" + id}})
+		}
+		writeJSON(t, w, containers)
+	})
+	dockerCommand := newFakeDockerCommand(t, mux)
+
+	type scenario struct {
+		class    string
+		expected []string
+	}
+
+	scenarios := []scenario{
+		{"success", []string{"migrate"}},
+		{"error", []string{"worker", "segfault"}},
+		{"killed", []string{"oom", "stopped"}},
+	}
+
+	for _, s := range scenarios {
+		containers, err := dockerCommand.ContainersByExitClass(s.class)
+		assert.NoError(t, err)
+		assert.EqualValues(t, s.expected, lo.Map(containers, func(ctr *Container, _ int) string { return ctr.Name }), s.class)
+	}
+
+	_, err := dockerCommand.ContainersByExitClass("crashed")
+	assert.EqualError(t, err, `unknown exit class "crashed", expected success, error or killed`)
+}
@@ -0,0 +1,15 @@
+//go:build !linux && !darwin && !freebsd && !windows
+
+package commands
+
+import (
+	"runtime"
+
+	"github.com/go-errors/errors"
+)
+
+// freeDiskSpace isn't supported on this platform because syscall doesn't give
+// us a portable way to stat a filesystem here
+func freeDiskSpace(path string) (int64, error) {
+	return 0, errors.New("checking free disk space is not supported on " + runtime.GOOS)
+}
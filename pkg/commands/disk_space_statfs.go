@@ -0,0 +1,16 @@
+//go:build linux || darwin || freebsd
+
+package commands
+
+import "syscall"
+
+// freeDiskSpace returns how many bytes are available to us on the filesystem
+// holding the given path
+func freeDiskSpace(path string) (int64, error) {
+	var stat syscall.Statfs_t
+	if err := syscall.Statfs(path, &stat); err != nil {
+		return 0, err
+	}
+
+	return int64(stat.Bavail) * int64(stat.Bsize), nil
+}
@@ -0,0 +1,19 @@
+package commands
+
+import "golang.org/x/sys/windows"
+
+// freeDiskSpace returns how many bytes are available to us on the filesystem
+// holding the given path
+func freeDiskSpace(path string) (int64, error) {
+	pathPtr, err := windows.UTF16PtrFromString(path)
+	if err != nil {
+		return 0, err
+	}
+
+	var available uint64
+	if err := windows.GetDiskFreeSpaceEx(pathPtr, &available, nil, nil); err != nil {
+		return 0, err
+	}
+
+	return int64(available), nil
+}
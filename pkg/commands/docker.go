@@ -16,6 +16,7 @@ import (
 	cliconfig "github.com/docker/cli/cli/config"
 	ddocker "github.com/docker/cli/cli/context/docker"
 	ctxstore "github.com/docker/cli/cli/context/store"
+	dockerTypes "github.com/docker/docker/api/types"
 	"github.com/docker/docker/api/types/container"
 	"github.com/docker/docker/client"
 	"github.com/imdario/mergo"
@@ -45,6 +46,9 @@ type DockerCommand struct {
 	ServiceMutex           deadlock.Mutex
 
 	Closers []io.Closer
+
+	restartHistory restartHistory
+	countHistory   countHistory
 }
 
 var _ io.Closer = &DockerCommand{}
@@ -187,6 +191,7 @@ func (c *DockerCommand) RefreshContainersAndServices(currentServices []*Service,
 	if err != nil {
 		return nil, nil, err
 	}
+	c.countHistory.record(containers)
 
 	var services []*Service
 	// we only need to get these services once because they won't change in the runtime of the program
@@ -242,27 +247,10 @@ func (c *DockerCommand) GetContainers(existingContainers []*Container) ([]*Conta
 
 		// initialise the container if it's completely new
 		if newContainer == nil {
-			newContainer = &Container{
-				ID:            ctr.ID,
-				Client:        c.Client,
-				OSCommand:     c.OSCommand,
-				Log:           c.Log,
-				DockerCommand: c,
-				Tr:            c.Tr,
-			}
+			newContainer = c.newContainer(ctr.ID)
 		}
 
-		newContainer.Container = ctr
-		// if the container is made with a name label we will use that
-		if name, ok := ctr.Labels["name"]; ok {
-			newContainer.Name = name
-		} else {
-			newContainer.Name = strings.TrimLeft(ctr.Names[0], "/")
-		}
-		newContainer.ServiceName = ctr.Labels["com.docker.compose.service"]
-		newContainer.ProjectName = ctr.Labels["com.docker.compose.project"]
-		newContainer.ContainerNumber = ctr.Labels["com.docker.compose.container"]
-		newContainer.OneOff = ctr.Labels["com.docker.compose.oneoff"] == "True"
+		setContainerSummary(newContainer, ctr)
 
 		ownContainers[i] = newContainer
 	}
@@ -272,6 +260,75 @@ func (c *DockerCommand) GetContainers(existingContainers []*Container) ([]*Conta
 	return ownContainers, nil
 }
 
+// watchContainersPollInterval is how often WatchForNewContainers checks for new
+// containers
+var watchContainersPollInterval = time.Second
+
+// WatchForNewContainers calls onNew for each container that appears after we
+// start watching, so that e.g. the UI can focus it. Containers that already
+// exist when we start don't count. Blocks until the context is done.
+func (c *DockerCommand) WatchForNewContainers(ctx context.Context, onNew func(*Container)) {
+	var seen map[string]bool
+
+	ticker := time.NewTicker(watchContainersPollInterval)
+	defer ticker.Stop()
+
+	for {
+		containers, err := c.Client.ContainerList(ctx, container.ListOptions{All: true})
+		if err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			c.Log.Error(err)
+		} else {
+			current := make(map[string]bool, len(containers))
+			for _, ctr := range containers {
+				current[ctr.ID] = true
+				// the first list is our baseline
+				if seen != nil && !seen[ctr.ID] {
+					newContainer := c.newContainer(ctr.ID)
+					setContainerSummary(newContainer, ctr)
+					onNew(newContainer)
+				}
+			}
+			seen = current
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (c *DockerCommand) newContainer(id string) *Container {
+	return &Container{
+		ID:            id,
+		Client:        c.Client,
+		OSCommand:     c.OSCommand,
+		Log:           c.Log,
+		DockerCommand: c,
+		Tr:            c.Tr,
+	}
+}
+
+// setContainerSummary populates our container from what docker gives us when
+// listing containers
+func setContainerSummary(newContainer *Container, ctr dockerTypes.Container) {
+	newContainer.Container = ctr
+	// if the container is made with a name label we will use that
+	if name, ok := ctr.Labels["name"]; ok {
+		newContainer.Name = name
+	} else {
+		newContainer.Name = strings.TrimLeft(ctr.Names[0], "/")
+	}
+	newContainer.ServiceName = ctr.Labels["com.docker.compose.service"]
+	newContainer.ProjectName = ctr.Labels["com.docker.compose.project"]
+	newContainer.ContainerNumber = ctr.Labels["com.docker.compose.container"]
+	newContainer.OneOff = ctr.Labels["com.docker.compose.oneoff"] == "True"
+}
+
 // GetServices gets services
 func (c *DockerCommand) GetServices() ([]*Service, error) {
 	if !c.InDockerComposeProject {
@@ -325,6 +382,7 @@ func (c *DockerCommand) SetContainerDetails(containers []*Container) {
 				c.Log.Error(err)
 			} else {
 				ctr.Details = details
+				c.restartHistory.observe(ctr.ID, ctr.Name, details.RestartCount)
 			}
 			wg.Done()
 		}()
@@ -0,0 +1,104 @@
+package commands
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	dockerTypes "github.com/docker/docker/api/types"
+	"github.com/docker/docker/client"
+	"github.com/stretchr/testify/assert"
+)
+
+// newFakeDockerCommand returns a dummy DockerCommand whose client talks to the
+// given handler instead of a real docker daemon
+func newFakeDockerCommand(t *testing.T, handler http.Handler) *DockerCommand {
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+
+	cli, err := client.NewClientWithOpts(
+		client.WithHost("tcp://"+server.Listener.Addr().String()),
+		client.WithVersion(APIVersion),
+		client.WithHTTPClient(server.Client()),
+	)
+	assert.NoError(t, err)
+
+	dockerCommand := NewDummyDockerCommand()
+	dockerCommand.Client = cli
+	return dockerCommand
+}
+
+// newFakeLocalDockerCommand is like newFakeDockerCommand but serves the fake
+// API over a unix socket, for testing things that need a local daemon
+func newFakeLocalDockerCommand(t *testing.T, handler http.Handler) *DockerCommand {
+	// unix socket paths have a short length limit, so we don't use t.TempDir
+	dir, err := os.MkdirTemp("", "lazydocker")
+	assert.NoError(t, err)
+	t.Cleanup(func() { _ = os.RemoveAll(dir) })
+
+	socketPath := filepath.Join(dir, "docker.sock")
+	listener, err := net.Listen("unix", socketPath)
+	assert.NoError(t, err)
+
+	server := httptest.NewUnstartedServer(handler)
+	server.Listener = listener
+	server.Start()
+	t.Cleanup(server.Close)
+
+	cli, err := client.NewClientWithOpts(
+		client.WithHost("unix://"+socketPath),
+		client.WithVersion(APIVersion),
+	)
+	assert.NoError(t, err)
+
+	dockerCommand := NewDummyDockerCommand()
+	dockerCommand.Client = cli
+	return dockerCommand
+}
+
+// writeJSON responds with the given value encoded as JSON
+func writeJSON(t *testing.T, w http.ResponseWriter, value interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	assert.NoError(t, json.NewEncoder(w).Encode(value))
+}
+
+// apiPath returns the versioned path that our client will request for the given
+// endpoint
+func apiPath(endpoint string) string {
+	return "/v" + APIVersion + endpoint
+}
+
+func TestDockerCommandWatchForNewContainers(t *testing.T) {
+	defaultInterval := watchContainersPollInterval
+	watchContainersPollInterval = time.Millisecond
+	defer func() { watchContainersPollInterval = defaultInterval }()
+
+	polls := 0
+	mux := http.NewServeMux()
+	mux.HandleFunc(apiPath("/containers/json"), func(w http.ResponseWriter, r *http.Request) {
+		polls++
+		containers := []dockerTypes.Container{{ID: "a", Names: []string{"/existing"}}}
+		if polls > 2 {
+			containers = append(containers, dockerTypes.Container{ID: "b", Names: []string{"/new"}})
+		}
+		writeJSON(t, w, containers)
+	})
+	dockerCommand := newFakeDockerCommand(t, mux)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	newNames := []string{}
+	dockerCommand.WatchForNewContainers(ctx, func(ctr *Container) {
+		newNames = append(newNames, ctr.Name)
+		cancel()
+	})
+
+	assert.EqualValues(t, []string{"new"}, newNames)
+}
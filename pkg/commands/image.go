@@ -2,12 +2,26 @@ package commands
 
 import (
 	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"sort"
 	"strings"
+	"time"
 
+	"github.com/distribution/reference"
+	dockerTypes "github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/container"
 	"github.com/docker/docker/api/types/filters"
 	"github.com/docker/docker/api/types/image"
 	"github.com/docker/docker/client"
+	"github.com/docker/docker/errdefs"
 	"github.com/fatih/color"
+	"github.com/go-errors/errors"
 	"github.com/jesseduffield/lazydocker/pkg/utils"
 	"github.com/samber/lo"
 	"github.com/sirupsen/logrus"
@@ -73,13 +87,13 @@ func getHistoryResponseItemDisplayStrings(layer image.HistoryResponseItem) []str
 	}
 }
 
-// RenderHistory renders the history of the image
-func (i *Image) RenderHistory() (string, error) {
-	history, err := i.Client.ImageHistory(context.Background(), i.ID)
-	if err != nil {
-		return "", err
-	}
+// History returns the layers of the image, newest first
+func (i *Image) History() ([]image.HistoryResponseItem, error) {
+	return i.Client.ImageHistory(context.Background(), i.ID)
+}
 
+// RenderHistory renders the given history, as returned by History, as a table
+func RenderHistory(history []image.HistoryResponseItem) (string, error) {
 	tableBody := lo.Map(history, func(layer image.HistoryResponseItem, _ int) []string {
 		return getHistoryResponseItemDisplayStrings(layer)
 	})
@@ -136,8 +150,499 @@ func (c *DockerCommand) RefreshImages() ([]*Image, error) {
 	return ownImages, nil
 }
 
+// compileRefPattern turns a pattern for matching image refs into a regexp.
+// Patterns wrapped in slashes, like /^nginx:1\.2\d$/, are treated as regular
+// expressions, and anything else is a glob where * matches any run of
+// characters and ? matches a single character, e.g. "myregistry.io/*:latest".
+func compileRefPattern(pattern string) (*regexp.Regexp, error) {
+	if len(pattern) >= 2 && strings.HasPrefix(pattern, "/") && strings.HasSuffix(pattern, "/") {
+		re, err := regexp.Compile(pattern[1 : len(pattern)-1])
+		if err != nil {
+			return nil, errors.Errorf("invalid image pattern %s: %v", pattern, err)
+		}
+		return re, nil
+	}
+
+	quoted := regexp.QuoteMeta(pattern)
+	quoted = strings.ReplaceAll(quoted, `\*`, ".*")
+	quoted = strings.ReplaceAll(quoted, `\?`, ".")
+	return regexp.MustCompile("^" + quoted + "$"), nil
+}
+
+// GetImagesMatching returns the images with a tag matching the given pattern
+// (see compileRefPattern for the pattern syntax)
+func (c *DockerCommand) GetImagesMatching(pattern string) ([]*Image, error) {
+	re, err := compileRefPattern(pattern)
+	if err != nil {
+		return nil, err
+	}
+
+	images, err := c.RefreshImages()
+	if err != nil {
+		return nil, err
+	}
+
+	return lo.Filter(images, func(img *Image, _ int) bool {
+		return lo.ContainsBy(img.Image.RepoTags, re.MatchString)
+	}), nil
+}
+
+// ImageDependencyTree maps each image ID to the IDs of the images built on top
+// of it, for drawing a tree of layered images. Docker only records parents for
+// images built locally (pulled images have none), so images whose parent is
+// unknown or no longer present are listed as roots under the empty key.
+func (c *DockerCommand) ImageDependencyTree() (map[string][]string, error) {
+	images, err := c.Client.ImageList(context.Background(), image.ListOptions{All: true})
+	if err != nil {
+		return nil, err
+	}
+
+	present := make(map[string]bool, len(images))
+	for _, img := range images {
+		present[img.ID] = true
+	}
+
+	tree := map[string][]string{}
+	for _, img := range images {
+		parent := img.ParentID
+		if !present[parent] {
+			parent = ""
+		}
+		tree[parent] = append(tree[parent], img.ID)
+	}
+
+	for _, children := range tree {
+		sort.Strings(children)
+	}
+
+	return tree, nil
+}
+
+// ErrImageNotBroken is returned by RepairImage when there's nothing wrong with
+// the image
+var ErrImageNotBroken = errors.New("image is not broken")
+
+// corruptImageErrors are the messages docker gives when inspecting an image
+// whose files are missing or damaged, as opposed to when something went wrong
+// talking to docker
+var corruptImageErrors = []string{
+	"layer does not exist",
+	"unknown blob",
+	"no such file or directory",
+	"unexpected end of JSON input",
+}
+
+// isCorruptImageError tells us whether the given error from inspecting an image
+// means the image itself is broken
+func isCorruptImageError(err error) bool {
+	if errdefs.IsDataLoss(err) {
+		return true
+	}
+
+	return lo.ContainsBy(corruptImageErrors, func(message string) bool {
+		return strings.Contains(err.Error(), message)
+	})
+}
+
+// RepairImage fixes an image left broken, e.g. by an interrupted pull or a
+// corrupted store, by removing it and pulling it again. We consider an image
+// broken when inspecting it reports no layers or fails with an error saying its
+// data is missing or damaged. Any other inspect error (e.g. the daemon timing
+// out) is returned as is, because removing a healthy image over a hiccup would
+// be far worse than not repairing a broken one. Images that look fine are left
+// alone and we return ErrImageNotBroken. The ref must be something we can pull,
+// so image IDs aren't accepted.
+func (c *DockerCommand) RepairImage(ref string) error {
+	if err := ValidateImageRef(ref); err != nil {
+		return err
+	}
+	if isImageID(ref) {
+		return errors.Errorf("can't repair image %s by ID as we'd have nothing to pull it by, use one of its tags instead", ref)
+	}
+
+	ctx := context.Background()
+
+	img, _, err := c.Client.ImageInspectWithRaw(ctx, ref)
+	if err != nil && !isCorruptImageError(err) {
+		return err
+	}
+	if err == nil && len(img.RootFS.Layers) > 0 {
+		return ErrImageNotBroken
+	}
+
+	c.Log.Warn(fmt.Sprintf("repairing broken image %s", ref))
+
+	if _, err := c.Client.ImageRemove(ctx, ref, image.RemoveOptions{Force: true}); err != nil && !errdefs.IsNotFound(err) {
+		return err
+	}
+
+	// we pull through the CLI so that it picks up the user's registry credentials
+	cmd := c.OSCommand.NewCmd("docker", "pull", ref)
+	_, err = sanitisedCommandOutput(cmd.Output())
+	return err
+}
+
 // PruneImages prunes images
 func (c *DockerCommand) PruneImages() error {
 	_, err := c.Client.ImagesPrune(context.Background(), filters.Args{})
 	return err
 }
+
+// ErrUnknownPullSize is returned by EstimatePullTime when the registry doesn't
+// tell us how big the image is
+var ErrUnknownPullSize = errors.New("unknown pull size")
+
+// manifestDescriptor is the part of `docker manifest inspect --verbose` output
+// that we care about. The CLI prints a single object for single-platform images
+// and an array of them for multi-platform images
+type manifestDescriptor struct {
+	Descriptor struct {
+		Platform struct {
+			Architecture string `json:"architecture"`
+			OS           string `json:"os"`
+		} `json:"platform"`
+	} `json:"Descriptor"`
+	SchemaV2Manifest *manifestLayers `json:"SchemaV2Manifest"`
+	OCIManifest      *manifestLayers `json:"OCIManifest"`
+}
+
+type manifestLayers struct {
+	Config struct {
+		Size int64 `json:"size"`
+	} `json:"config"`
+	Layers []struct {
+		Size int64 `json:"size"`
+	} `json:"layers"`
+}
+
+func (d manifestDescriptor) size() int64 {
+	manifest := d.SchemaV2Manifest
+	if manifest == nil {
+		manifest = d.OCIManifest
+	}
+	if manifest == nil {
+		return 0
+	}
+
+	total := manifest.Config.Size
+	for _, layer := range manifest.Layers {
+		total += layer.Size
+	}
+	return total
+}
+
+// parseManifestSize returns the compressed size of the image described by the
+// given manifest output, picking the entry matching our own platform when the
+// image is multi-platform
+func parseManifestSize(output string) (int64, error) {
+	output = strings.TrimSpace(output)
+	if strings.HasPrefix(output, "{") {
+		var descriptor manifestDescriptor
+		if err := json.Unmarshal([]byte(output), &descriptor); err != nil {
+			return 0, err
+		}
+		return descriptor.size(), nil
+	}
+
+	var descriptors []manifestDescriptor
+	if err := json.Unmarshal([]byte(output), &descriptors); err != nil {
+		return 0, err
+	}
+	if len(descriptors) == 0 {
+		return 0, nil
+	}
+
+	for _, descriptor := range descriptors {
+		platform := descriptor.Descriptor.Platform
+		if platform.OS == runtime.GOOS && platform.Architecture == runtime.GOARCH {
+			return descriptor.size(), nil
+		}
+	}
+	return descriptors[0].size(), nil
+}
+
+// EstimatePullTime estimates how long it will take to pull the given image
+// reference at the given bandwidth, based on the size reported by the registry.
+// Returns ErrUnknownPullSize if the registry doesn't report a size.
+func (c *DockerCommand) EstimatePullTime(ref string, bandwidthBytesPerSec int64) (time.Duration, error) {
+	if bandwidthBytesPerSec <= 0 {
+		return 0, errors.New("bandwidth must be greater than zero")
+	}
+	if err := ValidateImageRef(ref); err != nil {
+		return 0, err
+	}
+
+	cmd := c.OSCommand.NewCmd("docker", "manifest", "inspect", "--verbose", ref)
+	output, err := sanitisedCommandOutput(cmd.Output())
+	if err != nil {
+		return 0, err
+	}
+
+	size, err := parseManifestSize(output)
+	if err != nil {
+		return 0, err
+	}
+	if size == 0 {
+		return 0, ErrUnknownPullSize
+	}
+
+	seconds := float64(size) / float64(bandwidthBytesPerSec)
+	return time.Duration(seconds * float64(time.Second)), nil
+}
+
+// imageArchiveName returns a filesystem-friendly tar filename for an image ref
+func imageArchiveName(ref string) string {
+	return strings.NewReplacer("/", "_", ":", "_", "@", "_").Replace(ref) + ".tar"
+}
+
+// SaveAllImages saves every tagged image to its own tar file in the given
+// directory, returning the paths written. Dangling images are skipped. We keep
+// going when an image fails to save and return all the errors at the end.
+func (c *DockerCommand) SaveAllImages(dir string) ([]string, error) {
+	images, err := c.Client.ImageList(context.Background(), image.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, WrapError(err)
+	}
+
+	paths := []string{}
+	errs := []error{}
+	for _, img := range images {
+		ref, ok := lo.Find(img.RepoTags, func(tag string) bool { return tag != "<none>:<none>" })
+		if !ok {
+			continue
+		}
+
+		path := filepath.Join(dir, imageArchiveName(ref))
+		if err := c.saveImage(ref, path); err != nil {
+			errs = append(errs, errors.Errorf("%s: %v", ref, err))
+			continue
+		}
+		paths = append(paths, path)
+	}
+
+	return paths, errors.Join(errs...)
+}
+
+func (c *DockerCommand) saveImage(ref string, path string) error {
+	c.Log.Warn("saving image " + ref + " to " + path)
+
+	readCloser, err := c.Client.ImageSave(context.Background(), []string{ref})
+	if err != nil {
+		return err
+	}
+	defer readCloser.Close()
+
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+
+	if _, err := io.Copy(file, readCloser); err != nil {
+		file.Close()
+		return err
+	}
+
+	return file.Close()
+}
+
+// waitForImagePollInterval is how often WaitForImage checks for the image
+var waitForImagePollInterval = time.Second
+
+// WaitForImage blocks until the given image ref exists locally (e.g. because a
+// pull running in the background has finished) or the context is done. On
+// timeout the returned error includes whatever we last saw when looking for the
+// image.
+func (c *DockerCommand) WaitForImage(ctx context.Context, ref string) error {
+	ticker := time.NewTicker(waitForImagePollInterval)
+	defer ticker.Stop()
+
+	for {
+		_, _, err := c.Client.ImageInspectWithRaw(ctx, ref)
+		if err == nil {
+			return nil
+		}
+		if !errdefs.IsNotFound(err) && ctx.Err() == nil {
+			c.Log.Error(err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return errors.Errorf("gave up waiting for image %s: %v", ref, err)
+		case <-ticker.C:
+		}
+	}
+}
+
+// baseImageLabel is the OCI annotation that build tools like buildkit can set to
+// record which image was used in the FROM line
+const baseImageLabel = "org.opencontainers.image.base.name"
+
+// ImageBaseRef returns the reference of the image that the given image was built
+// on. We check the OCI base-name label first, then fall back to the image's
+// history, where any layers that came from a base image we have locally are
+// tagged with that image's tags. Returns an empty string if we can't tell.
+func (c *DockerCommand) ImageBaseRef(nameOrID string) (string, error) {
+	ctx := context.Background()
+
+	inspect, _, err := c.Client.ImageInspectWithRaw(ctx, nameOrID)
+	if err != nil {
+		return "", err
+	}
+	if inspect.Config != nil {
+		if base := inspect.Config.Labels[baseImageLabel]; base != "" {
+			return base, nil
+		}
+	}
+
+	history, err := c.Client.ImageHistory(ctx, nameOrID)
+	if err != nil {
+		return "", err
+	}
+
+	return baseRefFromHistory(history), nil
+}
+
+// BaseRef is like DockerCommand.ImageBaseRef but uses the labels we already
+// have from listing the image and the given history, as returned by History,
+// so that callers who need the history anyway only fetch it once
+func (i *Image) BaseRef(history []image.HistoryResponseItem) string {
+	if base := i.Image.Labels[baseImageLabel]; base != "" {
+		return base
+	}
+
+	return baseRefFromHistory(history)
+}
+
+func baseRefFromHistory(history []image.HistoryResponseItem) string {
+	// history is newest first, and the first entry is the image itself
+	for _, layer := range lo.Drop(history, 1) {
+		if len(layer.Tags) > 0 {
+			return layer.Tags[0]
+		}
+	}
+
+	return ""
+}
+
+// ContainersForImage returns all containers (running or not) that were created
+// from the given image. We match on image ID (full, or at least the 12
+// characters `docker images` shows), but also accept the image name the
+// container was created with, so an image ref works too
+func (c *DockerCommand) ContainersForImage(imageID string) ([]*Container, error) {
+	containers, err := c.Client.ContainerList(context.Background(), container.ListOptions{All: true})
+	if err != nil {
+		return nil, err
+	}
+
+	result := []*Container{}
+	for _, ctr := range containers {
+		if !containerUsesImage(ctr.ImageID, ctr.Image, imageID) {
+			continue
+		}
+
+		newContainer := c.newContainer(ctr.ID)
+		setContainerSummary(newContainer, ctr)
+		result = append(result, newContainer)
+	}
+
+	return result, nil
+}
+
+// ImageReclaimable returns roughly how much space pruning the images that no
+// container (running or not) was created from would get back. Images often
+// share layers, so rather than adding up image sizes we start from the size
+// of all layers, each counted once, and take away what the in-use images don't
+// share with any other image. This is the same figure `docker system df`
+// gives, and like it, it's an upper bound: a layer an in-use image shares
+// with an unused one is counted as reclaimable even though it will stay.
+func (c *DockerCommand) ImageReclaimable() (int64, error) {
+	diskUsage, err := c.Client.DiskUsage(context.Background(), dockerTypes.DiskUsageOptions{})
+	if err != nil {
+		return 0, err
+	}
+
+	var used int64
+	for _, img := range diskUsage.Images {
+		if img.Containers == 0 {
+			continue
+		}
+		if img.SharedSize == -1 {
+			// the daemon couldn't work out what's shared, so we assume nothing is
+			used += img.Size
+			continue
+		}
+		used += img.Size - img.SharedSize
+	}
+
+	return max(diskUsage.LayersSize-used, 0), nil
+}
+
+// containerUsesImage matches a short image ID against the start of the
+// container's image ID, but only if it's at least as long as the ones `docker
+// images` shows, so that something like "1" doesn't match half the containers
+func containerUsesImage(containerImageID string, containerImage string, imageID string) bool {
+	if containerImageID != "" && isImageID(imageID) {
+		id := strings.TrimPrefix(imageID, "sha256:")
+		if strings.HasPrefix(strings.TrimPrefix(containerImageID, "sha256:"), id) {
+			return true
+		}
+	}
+
+	return containerImage == imageID
+}
+
+// imageIDRegexp matches a full image ID or a short one as shown by `docker images`
+var imageIDRegexp = regexp.MustCompile(`^(sha256:)?[a-f0-9]{12,64}$`)
+
+// isImageID tells us whether the given ref is an image ID rather than a name
+func isImageID(ref string) bool {
+	return imageIDRegexp.MatchString(ref)
+}
+
+// ValidateImageRef checks that the given image reference is well-formed e.g.
+// "alpine", "alpine:3.19", "ghcr.io/foo/bar@sha256:<digest>" or an image ID, so
+// that we can give a friendlier error than docker would before trying to pull
+// or run it. This matters all the more because we pass refs to the docker CLI,
+// which would otherwise take a ref like "--privileged" as a flag.
+func ValidateImageRef(ref string) error {
+	if strings.TrimSpace(ref) == "" {
+		return errors.New("image reference is empty")
+	}
+	if isImageID(ref) {
+		return nil
+	}
+
+	if _, err := reference.ParseNormalizedNamed(ref); err != nil {
+		return errors.Errorf("invalid image reference %q: %v", ref, err)
+	}
+
+	return nil
+}
+
+// archMismatchWarning returns a warning if an image built for imageArch can't
+// run natively on hostArch. An image that doesn't report its architecture is
+// assumed to be fine
+func archMismatchWarning(imageArch string, hostArch string) (bool, string) {
+	if imageArch == "" || imageArch == hostArch {
+		return false, ""
+	}
+
+	return true, fmt.Sprintf("image is built for %s but this machine is %s, so it will run under emulation (if at all)", imageArch, hostArch)
+}
+
+// ImageArchMismatch tells us whether the given image was built for a different
+// CPU architecture to ours, along with a warning to show the user before they
+// run it
+func (c *DockerCommand) ImageArchMismatch(nameOrID string) (bool, string, error) {
+	inspect, _, err := c.Client.ImageInspectWithRaw(context.Background(), nameOrID)
+	if err != nil {
+		return false, "", err
+	}
+
+	mismatch, warning := archMismatchWarning(inspect.Architecture, runtime.GOARCH)
+	return mismatch, warning, nil
+}
@@ -0,0 +1,509 @@
+package commands
+
+import (
+	"context"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	dockerTypes "github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/image"
+	"github.com/samber/lo"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDockerCommandEstimatePullTime(t *testing.T) {
+	type scenario struct {
+		output    string
+		bandwidth int64
+		test      func(time.Duration, error)
+	}
+
+	scenarios := []scenario{
+		{
+			`{"Descriptor":{"size":500},"SchemaV2Manifest":{"config":{"size":1000},"layers":[{"size":4000},{"size":5000}]}}`,
+			1000,
+			func(estimate time.Duration, err error) {
+				assert.NoError(t, err)
+				assert.EqualValues(t, 10*time.Second, estimate)
+			},
+		},
+		{
+			`[{"Descriptor":{"platform":{"architecture":"unknown","os":"unknown"}},"OCIManifest":{"layers":[{"size":2000}]}}]`,
+			1000,
+			func(estimate time.Duration, err error) {
+				assert.NoError(t, err)
+				assert.EqualValues(t, 2*time.Second, estimate)
+			},
+		},
+		{
+			`{"Descriptor":{"size":500}}`,
+			1000,
+			func(estimate time.Duration, err error) {
+				assert.ErrorIs(t, err, ErrUnknownPullSize)
+			},
+		},
+		{
+			`{}`,
+			0,
+			func(estimate time.Duration, err error) {
+				assert.EqualError(t, err, "bandwidth must be greater than zero")
+			},
+		},
+	}
+
+	for _, s := range scenarios {
+		output := s.output
+		osCommand := NewDummyOSCommand()
+		osCommand.SetCommand(func(name string, args ...string) *exec.Cmd {
+			assert.EqualValues(t, "docker", name)
+			assert.EqualValues(t, []string{"manifest", "inspect", "--verbose", "alpine:latest"}, args)
+			return exec.Command("echo", output)
+		})
+
+		s.test(NewDummyDockerCommandWithOSCommand(osCommand).EstimatePullTime("alpine:latest", s.bandwidth))
+	}
+
+	_, err := NewDummyDockerCommand().EstimatePullTime("--insecure", 1000)
+	assert.EqualError(t, err, `invalid image reference "--insecure": invalid reference format`)
+}
+
+func TestDockerCommandSaveAllImages(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc(apiPath("/images/json"), func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(t, w, []image.Summary{
+			{ID: "sha256:1", RepoTags: []string{"alpine:3.19"}},
+			{ID: "sha256:2", RepoTags: []string{"<none>:<none>"}},
+			{ID: "sha256:3"},
+			{ID: "sha256:4", RepoTags: []string{"ghcr.io/foo/bar:1.0", "bar:latest"}},
+		})
+	})
+	mux.HandleFunc(apiPath("/images/get"), func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("tar:" + r.URL.Query().Get("names")))
+	})
+
+	dir := t.TempDir()
+	paths, err := newFakeDockerCommand(t, mux).SaveAllImages(dir)
+	assert.NoError(t, err)
+	assert.EqualValues(t, []string{
+		filepath.Join(dir, "alpine_3.19.tar"),
+		filepath.Join(dir, "ghcr.io_foo_bar_1.0.tar"),
+	}, paths)
+
+	content, err := os.ReadFile(filepath.Join(dir, "alpine_3.19.tar"))
+	assert.NoError(t, err)
+	assert.EqualValues(t, "tar:alpine:3.19", string(content))
+}
+
+func TestDockerCommandWaitForImage(t *testing.T) {
+	defaultInterval := waitForImagePollInterval
+	waitForImagePollInterval = time.Millisecond
+	defer func() { waitForImagePollInterval = defaultInterval }()
+
+	polls := 0
+	mux := http.NewServeMux()
+	mux.HandleFunc(apiPath("/images/alpine:latest/json"), func(w http.ResponseWriter, r *http.Request) {
+		polls++
+		if polls < 3 {
+			w.WriteHeader(http.StatusNotFound)
+			writeJSON(t, w, map[string]string{"message": "No such image: alpine:latest"})
+			return
+		}
+		writeJSON(t, w, map[string]string{"Id": "sha256:1"})
+	})
+	dockerCommand := newFakeDockerCommand(t, mux)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	assert.NoError(t, dockerCommand.WaitForImage(ctx, "alpine:latest"))
+	assert.EqualValues(t, 3, polls)
+
+	ctx, cancel = context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	err := dockerCommand.WaitForImage(ctx, "missing:latest")
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "gave up waiting for image missing:latest")
+}
+
+func TestDockerCommandImageBaseRef(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc(apiPath("/images/labeled/json"), func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(t, w, dockerTypes.ImageInspect{
+			ID:     "sha256:1",
+			Config: &container.Config{Labels: map[string]string{baseImageLabel: "docker.io/library/alpine:3.19"}},
+		})
+	})
+	mux.HandleFunc(apiPath("/images/layered/json"), func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(t, w, dockerTypes.ImageInspect{ID: "sha256:2", Config: &container.Config{}})
+	})
+	mux.HandleFunc(apiPath("/images/layered/history"), func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(t, w, []image.HistoryResponseItem{
+			{ID: "sha256:2", Tags: []string{"layered:latest"}},
+			{ID: "<missing>"},
+			{ID: "sha256:3", Tags: []string{"debian:bookworm"}},
+		})
+	})
+	mux.HandleFunc(apiPath("/images/scratch/json"), func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(t, w, dockerTypes.ImageInspect{ID: "sha256:4"})
+	})
+	mux.HandleFunc(apiPath("/images/scratch/history"), func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(t, w, []image.HistoryResponseItem{{ID: "sha256:4", Tags: []string{"scratch:latest"}}})
+	})
+	dockerCommand := newFakeDockerCommand(t, mux)
+
+	base, err := dockerCommand.ImageBaseRef("labeled")
+	assert.NoError(t, err)
+	assert.EqualValues(t, "docker.io/library/alpine:3.19", base)
+
+	base, err = dockerCommand.ImageBaseRef("layered")
+	assert.NoError(t, err)
+	assert.EqualValues(t, "debian:bookworm", base)
+
+	base, err = dockerCommand.ImageBaseRef("scratch")
+	assert.NoError(t, err)
+	assert.EqualValues(t, "", base)
+}
+
+func TestImageBaseRef(t *testing.T) {
+	history := []image.HistoryResponseItem{
+		{ID: "sha256:2", Tags: []string{"layered:latest"}},
+		{ID: "<missing>"},
+		{ID: "sha256:3", Tags: []string{"debian:bookworm"}},
+	}
+
+	labeled := &Image{Image: image.Summary{Labels: map[string]string{baseImageLabel: "docker.io/library/alpine:3.19"}}}
+	assert.EqualValues(t, "docker.io/library/alpine:3.19", labeled.BaseRef(history))
+
+	layered := &Image{Image: image.Summary{}}
+	assert.EqualValues(t, "debian:bookworm", layered.BaseRef(history))
+	assert.EqualValues(t, "", layered.BaseRef(history[:1]))
+	assert.EqualValues(t, "", layered.BaseRef(nil))
+}
+
+func TestDockerCommandContainersForImage(t *testing.T) {
+	webID := "sha256:1234abcd5678" + strings.Repeat("0", 52)
+	dbID := "sha256:9999ffff0000" + strings.Repeat("0", 52)
+	mux := http.NewServeMux()
+	mux.HandleFunc(apiPath("/containers/json"), func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(t, w, []dockerTypes.Container{
+			{ID: "a", Names: []string{"/web"}, ImageID: webID, Image: "nginx:latest"},
+			{ID: "b", Names: []string{"/worker"}, ImageID: webID, Image: webID},
+			{ID: "c", Names: []string{"/db"}, ImageID: dbID, Image: "postgres:16"},
+			{ID: "d", Names: []string{"/legacy"}, Image: "nginx:latest"},
+		})
+	})
+	dockerCommand := newFakeDockerCommand(t, mux)
+
+	type scenario struct {
+		testName string
+		imageID  string
+		expected []string
+	}
+
+	scenarios := []scenario{
+		{"full ID", webID, []string{"web", "worker"}},
+		{"full ID without prefix", strings.TrimPrefix(webID, "sha256:"), []string{"web", "worker"}},
+		{"short ID", "1234abcd5678", []string{"web", "worker"}},
+		{"short ID with prefix", "sha256:1234abcd5678", []string{"web", "worker"}},
+		{"ID prefix too short to be unique", "1234", []string{}},
+		{"ID prefix one character short", "1234abcd567", []string{}},
+		{"image name", "nginx:latest", []string{"web", "legacy"}},
+		{"unknown ID", "sha256:5555" + strings.Repeat("0", 60), []string{}},
+	}
+
+	for _, s := range scenarios {
+		t.Run(s.testName, func(t *testing.T) {
+			containers, err := dockerCommand.ContainersForImage(s.imageID)
+			assert.NoError(t, err)
+			assert.EqualValues(t, s.expected, lo.Map(containers, func(ctr *Container, _ int) string { return ctr.Name }))
+		})
+	}
+}
+
+func TestValidateImageRef(t *testing.T) {
+	validRefs := []string{
+		"alpine",
+		"alpine:3.19",
+		"library/alpine:latest",
+		"ghcr.io/jesseduffield/lazydocker:v0.23.0",
+		"localhost:5000/my-app",
+		"alpine@sha256:c5b1261d6d3e43071626931fc004f70149baeba2c8ec672bd4f27761f8e1ad6b",
+		"sha256:c5b1261d6d3e43071626931fc004f70149baeba2c8ec672bd4f27761f8e1ad6b",
+		"c5b1261d6d3e",
+	}
+	for _, ref := range validRefs {
+		assert.NoError(t, ValidateImageRef(ref), ref)
+	}
+
+	type scenario struct {
+		ref         string
+		expectedErr string
+	}
+
+	scenarios := []scenario{
+		{"", "image reference is empty"},
+		{"  ", "image reference is empty"},
+		{"Alpine", `invalid image reference "Alpine": invalid reference format: repository name (library/Alpine) must be lowercase`},
+		{"alpine:3.19 --rm", `invalid image reference "alpine:3.19 --rm": invalid reference format`},
+		{"alpine::latest", `invalid image reference "alpine::latest": invalid reference format`},
+		{"alpine@sha256:tooshort", `invalid image reference "alpine@sha256:tooshort": invalid reference format`},
+		{"--privileged", `invalid image reference "--privileged": invalid reference format`},
+	}
+
+	for _, s := range scenarios {
+		assert.EqualError(t, ValidateImageRef(s.ref), s.expectedErr)
+	}
+}
+
+func TestDockerCommandImageArchMismatch(t *testing.T) {
+	otherArch := "s390x"
+	if runtime.GOARCH == otherArch {
+		otherArch = "amd64"
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc(apiPath("/images/native/json"), func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(t, w, dockerTypes.ImageInspect{ID: "sha256:1", Architecture: runtime.GOARCH})
+	})
+	mux.HandleFunc(apiPath("/images/foreign/json"), func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(t, w, dockerTypes.ImageInspect{ID: "sha256:2", Architecture: otherArch})
+	})
+	dockerCommand := newFakeDockerCommand(t, mux)
+
+	mismatch, warning, err := dockerCommand.ImageArchMismatch("native")
+	assert.NoError(t, err)
+	assert.False(t, mismatch)
+	assert.EqualValues(t, "", warning)
+
+	mismatch, warning, err = dockerCommand.ImageArchMismatch("foreign")
+	assert.NoError(t, err)
+	assert.True(t, mismatch)
+	assert.EqualValues(t, "image is built for "+otherArch+" but this machine is "+runtime.GOARCH+", so it will run under emulation (if at all)", warning)
+}
+
+func TestDockerCommandImageReclaimable(t *testing.T) {
+	type scenario struct {
+		testName  string
+		diskUsage dockerTypes.DiskUsage
+		expected  int64
+	}
+
+	scenarios := []scenario{
+		{
+			// aaa and bbb share a 50 byte base layer, which is only counted once
+			testName: "shared layers",
+			diskUsage: dockerTypes.DiskUsage{
+				LayersSize: 1450,
+				Images: []*image.Summary{
+					{ID: "sha256:aaa", Size: 100, SharedSize: 50, Containers: 1},
+					{ID: "sha256:bbb", Size: 200, SharedSize: 50, Containers: 0},
+					{ID: "sha256:ccc", Size: 400, SharedSize: 0, Containers: 2},
+					{ID: "sha256:ddd", Size: 800, SharedSize: 0, Containers: 0},
+				},
+			},
+			// bbb and ddd, plus the base layer that aaa is still using, which
+			// is what makes this an upper bound
+			expected: 1000,
+		},
+		{
+			testName: "unknown shared size",
+			diskUsage: dockerTypes.DiskUsage{
+				LayersSize: 300,
+				Images: []*image.Summary{
+					{ID: "sha256:aaa", Size: 100, SharedSize: -1, Containers: 1},
+					{ID: "sha256:bbb", Size: 200, SharedSize: -1, Containers: 0},
+				},
+			},
+			expected: 200,
+		},
+		{
+			testName: "everything in use",
+			diskUsage: dockerTypes.DiskUsage{
+				LayersSize: 100,
+				Images: []*image.Summary{
+					{ID: "sha256:aaa", Size: 100, SharedSize: -1, Containers: 1},
+				},
+			},
+			expected: 0,
+		},
+	}
+
+	for _, s := range scenarios {
+		t.Run(s.testName, func(t *testing.T) {
+			mux := http.NewServeMux()
+			mux.HandleFunc(apiPath("/system/df"), func(w http.ResponseWriter, r *http.Request) {
+				writeJSON(t, w, s.diskUsage)
+			})
+
+			reclaimable, err := newFakeDockerCommand(t, mux).ImageReclaimable()
+			assert.NoError(t, err)
+			assert.EqualValues(t, s.expected, reclaimable)
+		})
+	}
+}
+
+func TestDockerCommandGetImagesMatching(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc(apiPath("/images/json"), func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(t, w, []image.Summary{
+			{ID: "sha256:a", RepoTags: []string{"registry.example.com/team/api:1.2"}},
+			{ID: "sha256:b", RepoTags: []string{"registry.example.com/team/web:latest", "web:latest"}},
+			{ID: "sha256:c", RepoTags: []string{"nginx:1.25"}},
+			{ID: "sha256:d", RepoTags: []string{"<none>:<none>"}},
+		})
+	})
+	dockerCommand := newFakeDockerCommand(t, mux)
+
+	type scenario struct {
+		pattern     string
+		expectedIDs []string
+		expectedErr string
+	}
+
+	scenarios := []scenario{
+		{"registry.example.com/*", []string{"sha256:a", "sha256:b"}, ""},
+		{"*:latest", []string{"sha256:b"}, ""},
+		{"nginx:1.2?", []string{"sha256:c"}, ""},
+		{"nginx", []string{}, ""},
+		{`/^nginx:1\.\d+$/`, []string{"sha256:c"}, ""},
+		{"/(unclosed/", nil, "invalid image pattern /(unclosed/: error parsing regexp: missing closing ): `(unclosed`"},
+	}
+
+	for _, s := range scenarios {
+		t.Run(s.pattern, func(t *testing.T) {
+			images, err := dockerCommand.GetImagesMatching(s.pattern)
+			if s.expectedErr != "" {
+				assert.EqualError(t, err, s.expectedErr)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.EqualValues(t, s.expectedIDs, lo.Map(images, func(img *Image, _ int) string { return img.ID }))
+		})
+	}
+}
+
+func TestDockerCommandImageDependencyTree(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc(apiPath("/images/json"), func(w http.ResponseWriter, r *http.Request) {
+		assert.EqualValues(t, "1", r.URL.Query().Get("all"))
+		writeJSON(t, w, []image.Summary{
+			{ID: "sha256:base"},
+			{ID: "sha256:deps", ParentID: "sha256:base"},
+			{ID: "sha256:app", ParentID: "sha256:deps"},
+			{ID: "sha256:tests", ParentID: "sha256:deps"},
+			{ID: "sha256:pulled"},
+			{ID: "sha256:orphan", ParentID: "sha256:deleted"},
+		})
+	})
+	dockerCommand := newFakeDockerCommand(t, mux)
+
+	tree, err := dockerCommand.ImageDependencyTree()
+	assert.NoError(t, err)
+	assert.EqualValues(t, map[string][]string{
+		"":            {"sha256:base", "sha256:orphan", "sha256:pulled"},
+		"sha256:base": {"sha256:deps"},
+		"sha256:deps": {"sha256:app", "sha256:tests"},
+	}, tree)
+}
+
+func TestDockerCommandRepairImage(t *testing.T) {
+	type scenario struct {
+		name           string
+		inspectStatus  int
+		inspectError   string
+		inspect        dockerTypes.ImageInspect
+		expectedRepair bool
+		test           func(error)
+	}
+
+	scenarios := []scenario{
+		{
+			"corrupt inspect",
+			http.StatusInternalServerError,
+			"layer does not exist",
+			dockerTypes.ImageInspect{},
+			true,
+			func(err error) { assert.NoError(t, err) },
+		},
+		{
+			"no layers",
+			http.StatusOK,
+			"",
+			dockerTypes.ImageInspect{ID: "sha256:abc"},
+			true,
+			func(err error) { assert.NoError(t, err) },
+		},
+		{
+			"healthy",
+			http.StatusOK,
+			"",
+			dockerTypes.ImageInspect{ID: "sha256:abc", RootFS: dockerTypes.RootFS{Type: "layers", Layers: []string{"sha256:layer"}}},
+			false,
+			func(err error) { assert.ErrorIs(t, err, ErrImageNotBroken) },
+		},
+		{
+			"missing",
+			http.StatusNotFound,
+			"No such image: alpine:latest",
+			dockerTypes.ImageInspect{},
+			false,
+			func(err error) { assert.Error(t, err) },
+		},
+		{
+			// a daemon error doesn't tell us anything about the image
+			"daemon error",
+			http.StatusInternalServerError,
+			"context deadline exceeded",
+			dockerTypes.ImageInspect{},
+			false,
+			func(err error) { assert.ErrorContains(t, err, "context deadline exceeded") },
+		},
+	}
+
+	for _, s := range scenarios {
+		t.Run(s.name, func(t *testing.T) {
+			removed := false
+			pulled := false
+
+			mux := http.NewServeMux()
+			mux.HandleFunc(apiPath("/images/alpine:latest/json"), func(w http.ResponseWriter, r *http.Request) {
+				if s.inspectStatus != http.StatusOK {
+					w.Header().Set("Content-Type", "application/json")
+					w.WriteHeader(s.inspectStatus)
+					writeJSON(t, w, map[string]string{"message": s.inspectError})
+					return
+				}
+				writeJSON(t, w, s.inspect)
+			})
+			mux.HandleFunc(apiPath("/images/alpine:latest"), func(w http.ResponseWriter, r *http.Request) {
+				assert.EqualValues(t, http.MethodDelete, r.Method)
+				assert.EqualValues(t, "1", r.URL.Query().Get("force"))
+				removed = true
+				writeJSON(t, w, []image.DeleteResponse{{Untagged: "alpine:latest"}})
+			})
+			dockerCommand := newFakeDockerCommand(t, mux)
+			dockerCommand.OSCommand.SetCommand(func(name string, args ...string) *exec.Cmd {
+				assert.EqualValues(t, "docker", name)
+				assert.EqualValues(t, []string{"pull", "alpine:latest"}, args)
+				pulled = true
+				return exec.Command("echo", "Status: Downloaded newer image for alpine:latest")
+			})
+
+			s.test(dockerCommand.RepairImage("alpine:latest"))
+			assert.EqualValues(t, s.expectedRepair, removed)
+			assert.EqualValues(t, s.expectedRepair, pulled)
+		})
+	}
+
+	for _, ref := range []string{"sha256:c5b1261d6d3e43071626931fc004f70149baeba2c8ec672bd4f27761f8e1ad6b", "c5b1261d6d3e"} {
+		err := NewDummyDockerCommand().RepairImage(ref)
+		assert.EqualError(t, err, "can't repair image "+ref+" by ID as we'd have nothing to pull it by, use one of its tags instead")
+	}
+}
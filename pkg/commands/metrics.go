@@ -0,0 +1,70 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/image"
+)
+
+// containerStates are the states docker puts containers in. We always report a
+// count for each of them, even when it's zero, so that scrapers see a stable
+// set of series.
+var containerStates = []string{"created", "running", "paused", "restarting", "removing", "exited", "dead"}
+
+// MetricsText renders what we can see of the docker daemon in the Prometheus
+// text exposition format, so that it can be scraped by a monitoring sidecar.
+// The metrics are:
+//
+//	lazydocker_containers{state="..."}   number of containers in each state
+//	lazydocker_images                    number of images (excluding intermediate layers)
+//	lazydocker_cpu_usage_percent         combined CPU usage of running containers
+//	lazydocker_memory_usage_bytes        combined memory usage of running containers
+//
+// These names are relied on by whoever is scraping them, so don't change them.
+func (c *DockerCommand) MetricsText() (string, error) {
+	ctx := context.Background()
+
+	containers, err := c.Client.ContainerList(ctx, container.ListOptions{All: true})
+	if err != nil {
+		return "", err
+	}
+
+	images, err := c.Client.ImageList(ctx, image.ListOptions{})
+	if err != nil {
+		return "", err
+	}
+
+	stats, err := c.systemStats(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	stateCounts := map[string]int{}
+	for _, ctr := range containers {
+		stateCounts[ctr.State]++
+	}
+
+	var b strings.Builder
+	writeGauge := func(name string, help string) {
+		fmt.Fprintf(&b, "# HELP %s %s\n# TYPE %s gauge\n", name, help, name)
+	}
+
+	writeGauge("lazydocker_containers", "Number of containers in each state.")
+	for _, state := range containerStates {
+		fmt.Fprintf(&b, "lazydocker_containers{state=%q} %d\n", state, stateCounts[state])
+	}
+
+	writeGauge("lazydocker_images", "Number of images.")
+	fmt.Fprintf(&b, "lazydocker_images %d\n", len(images))
+
+	writeGauge("lazydocker_cpu_usage_percent", "Combined CPU usage of running containers, as a percentage of the host's total CPU.")
+	fmt.Fprintf(&b, "lazydocker_cpu_usage_percent %g\n", stats.CPUPercentage)
+
+	writeGauge("lazydocker_memory_usage_bytes", "Combined memory usage of running containers.")
+	fmt.Fprintf(&b, "lazydocker_memory_usage_bytes %d\n", stats.MemoryUsage)
+
+	return b.String(), nil
+}
@@ -0,0 +1,56 @@
+package commands
+
+import (
+	"net/http"
+	"testing"
+
+	dockerTypes "github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/image"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDockerCommandMetricsText(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc(apiPath("/containers/json"), func(w http.ResponseWriter, r *http.Request) {
+		// we list every container for the counts, but only running ones for stats
+		if r.URL.Query().Get("all") != "1" {
+			writeJSON(t, w, []dockerTypes.Container{{ID: "web", State: "running"}})
+			return
+		}
+		writeJSON(t, w, []dockerTypes.Container{
+			{ID: "web", State: "running"},
+			{ID: "migrate", State: "exited"},
+			{ID: "job", State: "exited"},
+			{ID: "debug", State: "paused"},
+		})
+	})
+	mux.HandleFunc(apiPath("/images/json"), func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(t, w, []image.Summary{{ID: "sha256:a"}, {ID: "sha256:b"}, {ID: "sha256:c"}})
+	})
+	mux.HandleFunc(apiPath("/containers/web/stats"), func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"cpu_stats":{"cpu_usage":{"total_usage":375},"system_cpu_usage":2000},"precpu_stats":{"cpu_usage":{"total_usage":250},"system_cpu_usage":1000},"memory_stats":{"usage":52428800}}`))
+	})
+	dockerCommand := newFakeDockerCommand(t, mux)
+
+	text, err := dockerCommand.MetricsText()
+	assert.NoError(t, err)
+	assert.EqualValues(t, `# HELP lazydocker_containers Number of containers in each state.
+# TYPE lazydocker_containers gauge
+lazydocker_containers{state="created"} 0
+lazydocker_containers{state="running"} 1
+lazydocker_containers{state="paused"} 1
+lazydocker_containers{state="restarting"} 0
+lazydocker_containers{state="removing"} 0
+lazydocker_containers{state="exited"} 2
+lazydocker_containers{state="dead"} 0
+# HELP lazydocker_images Number of images.
+# TYPE lazydocker_images gauge
+lazydocker_images 3
+# HELP lazydocker_cpu_usage_percent Combined CPU usage of running containers, as a percentage of the host's total CPU.
+# TYPE lazydocker_cpu_usage_percent gauge
+lazydocker_cpu_usage_percent 12.5
+# HELP lazydocker_memory_usage_bytes Combined memory usage of running containers.
+# TYPE lazydocker_memory_usage_bytes gauge
+lazydocker_memory_usage_bytes 52428800
+`, text)
+}
@@ -0,0 +1,60 @@
+package commands
+
+import (
+	"context"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// pollStream calls poll straight away, returning its error if it fails, and
+// then again at the given interval, sending each result on the returned
+// channel. The channel is closed once the context is done. When a later poll
+// fails we log it and, if stopOnError is set, close the channel; otherwise we
+// try again at the next tick, so that a blip doesn't end the stream for good.
+func pollStream[T any](ctx context.Context, log *logrus.Entry, interval time.Duration, stopOnError bool, poll func(context.Context) (T, error)) (<-chan T, error) {
+	first, err := poll(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make(chan T)
+	go func() {
+		defer close(results)
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		result := first
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case results <- result:
+			}
+
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case <-ticker.C:
+				}
+
+				next, err := poll(ctx)
+				if err == nil {
+					result = next
+					break
+				}
+				if ctx.Err() != nil {
+					return
+				}
+				log.Error(err)
+				if stopOnError {
+					return
+				}
+			}
+		}
+	}()
+
+	return results, nil
+}
@@ -0,0 +1,94 @@
+package commands
+
+import (
+	"time"
+
+	"github.com/go-errors/errors"
+	"github.com/sasha-s/go-deadlock"
+)
+
+// maxRestartHistory is how many restarts we remember per container. We only
+// need enough to tell whether a container is crash-looping
+const maxRestartHistory = 10
+
+// restartHistory keeps track of when we've seen each container's restart count
+// go up. Docker only gives us the total count, so the timestamps are when we
+// observed the change rather than when the restart actually happened, which is
+// close enough given we refresh container details every few seconds.
+// The zero value is ready to use.
+type restartHistory struct {
+	mutex deadlock.RWMutex
+	// now is swapped out in tests
+	now func() time.Time
+
+	counts   map[string]int
+	restarts map[string][]time.Time
+	ids      map[string]string
+}
+
+func (h *restartHistory) currentTime() time.Time {
+	if h.now == nil {
+		return time.Now()
+	}
+	return h.now()
+}
+
+// init lazily allocates our maps. Must be called with the write lock held
+func (h *restartHistory) init() {
+	if h.counts == nil {
+		h.counts = map[string]int{}
+		h.restarts = map[string][]time.Time{}
+		h.ids = map[string]string{}
+	}
+}
+
+// observe records the restart count we've just seen for a container
+func (h *restartHistory) observe(id string, name string, restartCount int) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	h.init()
+
+	h.ids[name] = id
+
+	previous, seen := h.counts[id]
+	h.counts[id] = restartCount
+	if !seen || restartCount <= previous {
+		return
+	}
+
+	now := h.currentTime()
+	for i := previous; i < restartCount; i++ {
+		h.restarts[id] = append(h.restarts[id], now)
+	}
+	if len(h.restarts[id]) > maxRestartHistory {
+		h.restarts[id] = h.restarts[id][len(h.restarts[id])-maxRestartHistory:]
+	}
+}
+
+// count returns how many restarts we've seen for the container within the window
+func (h *restartHistory) count(nameOrID string, window time.Duration) (int, error) {
+	h.mutex.RLock()
+	defer h.mutex.RUnlock()
+
+	id := nameOrID
+	if _, ok := h.counts[id]; !ok {
+		if id, ok = h.ids[nameOrID]; !ok {
+			return 0, errors.New("no restart history for container " + nameOrID)
+		}
+	}
+
+	cutoff := h.currentTime().Add(-window)
+	count := 0
+	for _, restartedAt := range h.restarts[id] {
+		if restartedAt.After(cutoff) {
+			count++
+		}
+	}
+	return count, nil
+}
+
+// RestartRate returns how many times we've seen the given container restart
+// within the window, so that crash-looping containers can be flagged
+func (c *DockerCommand) RestartRate(nameOrID string, window time.Duration) (int, error) {
+	return c.restartHistory.count(nameOrID, window)
+}
@@ -0,0 +1,81 @@
+package commands
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDockerCommandRestartRate(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	dockerCommand := NewDummyDockerCommand()
+	dockerCommand.restartHistory.now = func() time.Time { return now }
+
+	_, err := dockerCommand.RestartRate("web", time.Minute)
+	assert.EqualError(t, err, "no restart history for container web")
+
+	// the first observation is our baseline, so it doesn't count as restarts
+	dockerCommand.restartHistory.observe("abc", "web", 3)
+	rate, err := dockerCommand.RestartRate("web", time.Minute)
+	assert.NoError(t, err)
+	assert.EqualValues(t, 0, rate)
+
+	now = now.Add(10 * time.Second)
+	dockerCommand.restartHistory.observe("abc", "web", 4)
+	now = now.Add(10 * time.Second)
+	dockerCommand.restartHistory.observe("abc", "web", 6)
+	now = now.Add(10 * time.Second)
+	dockerCommand.restartHistory.observe("abc", "web", 6)
+
+	rate, err = dockerCommand.RestartRate("abc", time.Minute)
+	assert.NoError(t, err)
+	assert.EqualValues(t, 3, rate)
+
+	rate, err = dockerCommand.RestartRate("web", 15*time.Second)
+	assert.NoError(t, err)
+	assert.EqualValues(t, 2, rate)
+
+	now = now.Add(time.Hour)
+	rate, err = dockerCommand.RestartRate("web", time.Minute)
+	assert.NoError(t, err)
+	assert.EqualValues(t, 0, rate)
+
+	dockerCommand.restartHistory.observe("abc", "web", 6+maxRestartHistory+5)
+	rate, err = dockerCommand.RestartRate("web", time.Minute)
+	assert.NoError(t, err)
+	assert.EqualValues(t, maxRestartHistory, rate)
+}
+
+// SetContainerDetails observes restart counts from one goroutine per container
+// while the UI may be asking for restart rates, so this should be run with -race
+func TestRestartHistoryConcurrentAccess(t *testing.T) {
+	history := &restartHistory{}
+
+	wg := sync.WaitGroup{}
+	for i := 0; i < 20; i++ {
+		id := fmt.Sprintf("container-%d", i)
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			for count := 0; count < 50; count++ {
+				history.observe(id, id, count)
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 50; j++ {
+				_, _ = history.count(id, time.Minute)
+			}
+		}()
+	}
+	wg.Wait()
+
+	for i := 0; i < 20; i++ {
+		count, err := history.count(fmt.Sprintf("container-%d", i), time.Minute)
+		assert.NoError(t, err)
+		assert.EqualValues(t, maxRestartHistory, count)
+	}
+}
@@ -0,0 +1,384 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/docker/docker/api/types/mount"
+	"github.com/go-errors/errors"
+	"github.com/samber/lo"
+)
+
+// RunConfig is everything we need to recreate a container with `docker run`,
+// and is where any `docker run` option we support lives. Call Validate before
+// running a container with the args from Args.
+type RunConfig struct {
+	Name  string `json:"name,omitempty"`
+	Image string `json:"image"`
+	// Entrypoint replaces the image's entrypoint when set
+	Entrypoint []string `json:"entrypoint,omitempty"`
+	Cmd        []string `json:"cmd,omitempty"`
+	Env        []string `json:"env,omitempty"`
+	// Ports are in docker's `[hostIP:]hostPort:containerPort/protocol` form
+	Ports []string `json:"ports,omitempty"`
+	// Mounts are in docker's `source:destination[:ro]` form, where the source
+	// is a host path for bind mounts or a volume name
+	Mounts  []string          `json:"mounts,omitempty"`
+	Network string            `json:"network,omitempty"`
+	Labels  map[string]string `json:"labels,omitempty"`
+	// RestartPolicy is in docker's `name[:maxRetries]` form, e.g. "on-failure:3"
+	RestartPolicy string `json:"restartPolicy,omitempty"`
+	// Tty and Interactive allocate a pseudo-TTY and keep stdin open, just like
+	// docker's `--tty` and `--interactive`
+	Tty         bool `json:"tty,omitempty"`
+	Interactive bool `json:"interactive,omitempty"`
+	// User is who to run as, in docker's `name|uid[:group|gid]` form
+	User string `json:"user,omitempty"`
+	// HealthCmd is a shell command to check the container's health with. The
+	// interval and retries are left to docker's defaults when zero
+	HealthCmd      string        `json:"healthCmd,omitempty"`
+	HealthInterval time.Duration `json:"healthInterval,omitempty"`
+	HealthRetries  int           `json:"healthRetries,omitempty"`
+	// ReadOnly mounts the container's root filesystem read-only, usually along
+	// with some Tmpfs mounts for scratch space
+	ReadOnly bool `json:"readOnly,omitempty"`
+	// Tmpfs are in docker's `destination[:options]` form
+	Tmpfs []string `json:"tmpfs,omitempty"`
+	// Ulimits are in docker's `name=soft[:hard]` form, e.g. "nofile=1024:4096"
+	Ulimits []string `json:"ulimits,omitempty"`
+	// Init runs an init process in the container to reap zombies
+	Init bool `json:"init,omitempty"`
+	// StopSignal is the signal to stop the container with, by name (e.g.
+	// "SIGINT" or "INT") or number
+	StopSignal string `json:"stopSignal,omitempty"`
+	// LabelFile is the path of a file on our side of lines of key=value labels,
+	// applied along with Labels
+	LabelFile string `json:"labelFile,omitempty"`
+	// WorkingDir is the container's starting directory, which must be absolute
+	WorkingDir string `json:"workingDir,omitempty"`
+	// Memory is the memory limit in bytes, 0 meaning no limit
+	Memory int64 `json:"memory,omitempty"`
+	// MemoryReservation is the soft memory limit in bytes, which can't exceed
+	// Memory when both are set
+	MemoryReservation int64 `json:"memoryReservation,omitempty"`
+	// NanoCPUs is the CPU limit in billionths of a CPU, 0 meaning no limit
+	NanoCPUs int64 `json:"nanoCpus,omitempty"`
+}
+
+// SnapshotRunConfig captures how the given container was run, so that it can
+// be recreated identically (e.g. after changing its env). Anonymous volumes
+// are left out because recreating the container would give it new ones anyway.
+// We don't capture everything docker run can set: a container's capabilities,
+// devices, privileged mode, security options, DNS settings and extra hosts,
+// hostname, log driver, CPU shares and pids limit, tmpfs mounts made with
+// --mount rather than --tmpfs, and shell-less healthchecks are all left out.
+// We can't capture a label file either, as docker only keeps the labels.
+func (c *DockerCommand) SnapshotRunConfig(nameOrID string) (*RunConfig, error) {
+	details, err := c.Client.ContainerInspect(context.Background(), nameOrID)
+	if err != nil {
+		return nil, err
+	}
+
+	runConfig := &RunConfig{
+		Name: strings.TrimPrefix(details.Name, "/"),
+	}
+
+	if details.Config != nil {
+		runConfig.Image = details.Config.Image
+		runConfig.Entrypoint = details.Config.Entrypoint
+		runConfig.Cmd = details.Config.Cmd
+		runConfig.Labels = details.Config.Labels
+		runConfig.Tty = details.Config.Tty
+		runConfig.Interactive = details.Config.OpenStdin
+		runConfig.Env = details.Config.Env
+		runConfig.WorkingDir = details.Config.WorkingDir
+		runConfig.User = details.Config.User
+		runConfig.StopSignal = details.Config.StopSignal
+		// `--health-cmd` always runs through a shell, so that's the only kind
+		// of healthcheck we can carry over
+		if healthcheck := details.Config.Healthcheck; healthcheck != nil && len(healthcheck.Test) == 2 && healthcheck.Test[0] == "CMD-SHELL" {
+			runConfig.HealthCmd = healthcheck.Test[1]
+			runConfig.HealthInterval = healthcheck.Interval
+			runConfig.HealthRetries = healthcheck.Retries
+		}
+	}
+
+	if details.HostConfig != nil {
+		for port, bindings := range details.HostConfig.PortBindings {
+			for _, binding := range bindings {
+				mapping := binding.HostPort + ":" + string(port)
+				if binding.HostIP != "" {
+					// IPv6 addresses have colons of their own, so docker needs
+					// them in brackets to tell where the address ends
+					hostIP := binding.HostIP
+					if strings.Contains(hostIP, ":") {
+						hostIP = "[" + hostIP + "]"
+					}
+					mapping = hostIP + ":" + mapping
+				}
+				runConfig.Ports = append(runConfig.Ports, mapping)
+			}
+		}
+		// port bindings come from a map so we sort them to get a stable order
+		sort.Strings(runConfig.Ports)
+
+		if networkMode := string(details.HostConfig.NetworkMode); networkMode != "default" {
+			runConfig.Network = networkMode
+		}
+		if restartPolicy := details.HostConfig.RestartPolicy; restartPolicy.Name != "" && restartPolicy.Name != "no" {
+			runConfig.RestartPolicy = string(restartPolicy.Name)
+			if restartPolicy.MaximumRetryCount > 0 {
+				runConfig.RestartPolicy += ":" + strconv.Itoa(restartPolicy.MaximumRetryCount)
+			}
+		}
+		runConfig.ReadOnly = details.HostConfig.ReadonlyRootfs
+		runConfig.Init = details.HostConfig.Init != nil && *details.HostConfig.Init
+		for destination, options := range details.HostConfig.Tmpfs {
+			if options != "" {
+				destination += ":" + options
+			}
+			runConfig.Tmpfs = append(runConfig.Tmpfs, destination)
+		}
+		sort.Strings(runConfig.Tmpfs)
+		for _, ulimit := range details.HostConfig.Ulimits {
+			runConfig.Ulimits = append(runConfig.Ulimits, fmt.Sprintf("%s=%d:%d", ulimit.Name, ulimit.Soft, ulimit.Hard))
+		}
+		runConfig.Memory = details.HostConfig.Memory
+		runConfig.MemoryReservation = details.HostConfig.MemoryReservation
+		runConfig.NanoCPUs = details.HostConfig.NanoCPUs
+	}
+
+	for _, mountPoint := range details.Mounts {
+		source := mountPoint.Source
+		switch mountPoint.Type {
+		case mount.TypeBind:
+		case mount.TypeVolume:
+			// anonymous volumes are named after a 64 character hex ID, just like
+			// a container. We can't tell them apart any other way
+			if len(mountPoint.Name) == 64 {
+				continue
+			}
+			source = mountPoint.Name
+		default:
+			continue
+		}
+
+		volume := source + ":" + mountPoint.Destination
+		if !mountPoint.RW {
+			volume += ":ro"
+		}
+		runConfig.Mounts = append(runConfig.Mounts, volume)
+	}
+
+	return runConfig, nil
+}
+
+// stopSignalRegexp matches the forms docker accepts a stop signal in, e.g.
+// "SIGTERM", "TERM", "SIGRTMIN+3" or "15"
+var stopSignalRegexp = regexp.MustCompile(`^((SIG)?[A-Z][A-Z0-9]*([+-][0-9]+)?|[0-9]+)$`)
+
+// ulimitRegexp matches docker's `name=soft[:hard]` ulimit form, where -1 means
+// unlimited
+var ulimitRegexp = regexp.MustCompile(`^([a-z]+)=(-?[0-9]+)(?::(-?[0-9]+))?$`)
+
+// validateUlimit checks a ulimit is well-formed, with a soft limit no higher
+// than its hard limit
+func validateUlimit(ulimit string) error {
+	match := ulimitRegexp.FindStringSubmatch(ulimit)
+	if match == nil {
+		return errors.Errorf("invalid ulimit %q, expected name=soft[:hard]", ulimit)
+	}
+	if match[3] == "" {
+		return nil
+	}
+
+	soft, _ := strconv.ParseInt(match[2], 10, 64)
+	hard, _ := strconv.ParseInt(match[3], 10, 64)
+	if hard != -1 && (soft == -1 || soft > hard) {
+		return errors.Errorf("invalid ulimit %q, the soft limit can't exceed the hard limit", ulimit)
+	}
+
+	return nil
+}
+
+// restartPolicyRegexp matches the restart policies docker accepts
+var restartPolicyRegexp = regexp.MustCompile(`^(no|always|unless-stopped|on-failure(:[0-9]+)?)$`)
+
+// Validate returns an error if the config couldn't be used to run a container,
+// so that we can catch it before handing the args from Args to docker
+func (r *RunConfig) Validate() error {
+	// the working dir is a path within the container, which is always
+	// slash-separated regardless of our own OS
+	if r.WorkingDir != "" && !path.IsAbs(r.WorkingDir) {
+		return errors.Errorf("working dir %q must be an absolute path", r.WorkingDir)
+	}
+	if r.Memory != 0 && r.MemoryReservation > r.Memory {
+		return errors.Errorf("memory reservation (%d bytes) can't exceed the memory limit (%d bytes)", r.MemoryReservation, r.Memory)
+	}
+	if strings.ContainsAny(r.User, " \t\n") || strings.Count(r.User, ":") > 1 {
+		return errors.Errorf("invalid user %q, expected name|uid[:group|gid]", r.User)
+	}
+	if r.HealthInterval < 0 || r.HealthRetries < 0 {
+		return errors.New("health interval and retries can't be negative")
+	}
+	for _, tmpfs := range r.Tmpfs {
+		if destination := strings.SplitN(tmpfs, ":", 2)[0]; !path.IsAbs(destination) {
+			return errors.Errorf("tmpfs destination %q must be an absolute path", destination)
+		}
+	}
+	if r.RestartPolicy != "" && !restartPolicyRegexp.MatchString(r.RestartPolicy) {
+		return errors.Errorf("invalid restart policy %q, expected no, always, unless-stopped or on-failure[:max-retries]", r.RestartPolicy)
+	}
+	for _, ulimit := range r.Ulimits {
+		if err := validateUlimit(ulimit); err != nil {
+			return err
+		}
+	}
+	if r.StopSignal != "" && !stopSignalRegexp.MatchString(r.StopSignal) {
+		return errors.Errorf("invalid stop signal %q, expected e.g. SIGTERM or 15", r.StopSignal)
+	}
+	for key := range r.Labels {
+		// docker splits `--label key=value` on the first '=', so a key can't
+		// contain one
+		if key == "" || strings.ContainsAny(key, "= \t\n") {
+			return errors.Errorf("invalid label key %q", key)
+		}
+	}
+	if r.LabelFile != "" {
+		file, err := os.Open(r.LabelFile)
+		if err != nil {
+			return errors.Errorf("could not read label file: %v", err)
+		}
+		file.Close()
+	}
+
+	return nil
+}
+
+// Args returns the arguments to pass to the docker CLI to run a container with
+// this config, detached
+func (r *RunConfig) Args() []string {
+	args := []string{"run", "--detach"}
+	if r.Name != "" {
+		args = append(args, "--name", r.Name)
+	}
+	for _, env := range r.Env {
+		args = append(args, "--env", env)
+	}
+	for _, port := range r.Ports {
+		args = append(args, "--publish", port)
+	}
+	for _, volume := range r.Mounts {
+		args = append(args, "--volume", volume)
+	}
+	if r.Network != "" {
+		args = append(args, "--network", r.Network)
+	}
+	if r.WorkingDir != "" {
+		args = append(args, "--workdir", r.WorkingDir)
+	}
+	if r.RestartPolicy != "" {
+		args = append(args, "--restart", r.RestartPolicy)
+	}
+	if r.Tty {
+		args = append(args, "--tty")
+	}
+	if r.Interactive {
+		args = append(args, "--interactive")
+	}
+	if r.User != "" {
+		args = append(args, "--user", r.User)
+	}
+	if r.ReadOnly {
+		args = append(args, "--read-only")
+	}
+	for _, tmpfs := range r.Tmpfs {
+		args = append(args, "--tmpfs", tmpfs)
+	}
+	for _, ulimit := range r.Ulimits {
+		args = append(args, "--ulimit", ulimit)
+	}
+	if r.Init {
+		args = append(args, "--init")
+	}
+	if r.StopSignal != "" {
+		args = append(args, "--stop-signal", r.StopSignal)
+	}
+	if r.HealthCmd != "" {
+		args = append(args, "--health-cmd", r.HealthCmd)
+	}
+	if r.HealthInterval != 0 {
+		args = append(args, "--health-interval", r.HealthInterval.String())
+	}
+	if r.HealthRetries != 0 {
+		args = append(args, "--health-retries", strconv.Itoa(r.HealthRetries))
+	}
+	// labels come from a map so we sort them to get a stable order
+	labelKeys := lo.Keys(r.Labels)
+	sort.Strings(labelKeys)
+	for _, key := range labelKeys {
+		args = append(args, "--label", key+"="+r.Labels[key])
+	}
+	if r.LabelFile != "" {
+		args = append(args, "--label-file", r.LabelFile)
+	}
+	if r.Memory != 0 {
+		args = append(args, "--memory", strconv.FormatInt(r.Memory, 10))
+	}
+	if r.MemoryReservation != 0 {
+		args = append(args, "--memory-reservation", strconv.FormatInt(r.MemoryReservation, 10))
+	}
+	if r.NanoCPUs != 0 {
+		args = append(args, "--cpus", strconv.FormatFloat(float64(r.NanoCPUs)/1e9, 'f', -1, 64))
+	}
+
+	// --entrypoint only takes the executable, so the rest of the entrypoint
+	// goes ahead of the command
+	entrypointArgs := []string{}
+	if len(r.Entrypoint) > 0 {
+		args = append(args, "--entrypoint", r.Entrypoint[0])
+		entrypointArgs = r.Entrypoint[1:]
+	}
+
+	args = append(args, r.Image)
+	args = append(args, entrypointArgs...)
+	return append(args, r.Cmd...)
+}
+
+// shellSafeArgRegexp matches arguments that don't need quoting in a shell
+var shellSafeArgRegexp = regexp.MustCompile(`^[A-Za-z0-9_./:=,@%+-]+$`)
+
+// shellQuote quotes the given argument for a POSIX shell. Within single quotes
+// nothing is special (not even `!` in interactive bash), so we only need to
+// deal with single quotes themselves, by closing the quotes, adding an escaped
+// quote and opening them again
+func shellQuote(arg string) string {
+	if shellSafeArgRegexp.MatchString(arg) {
+		return arg
+	}
+
+	return "'" + strings.ReplaceAll(arg, "'", `'\''`) + "'"
+}
+
+// ReproduceRunCommand returns a `docker run` command that would recreate the
+// given container, quoted for pasting into a POSIX shell or a bug report. We
+// don't quote for the user's own platform because the command is as likely to
+// end up in a bug report as in their terminal. Note that this includes the
+// container's env vars, which may contain secrets.
+func (c *DockerCommand) ReproduceRunCommand(nameOrID string) (string, error) {
+	runConfig, err := c.SnapshotRunConfig(nameOrID)
+	if err != nil {
+		return "", err
+	}
+
+	args := lo.Map(runConfig.Args(), func(arg string, _ int) string { return shellQuote(arg) })
+
+	return "docker " + strings.Join(args, " "), nil
+}
@@ -0,0 +1,418 @@
+package commands
+
+import (
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// runConfigFixture serves an inspect of a container named web with a bit of
+// everything that SnapshotRunConfig captures
+func runConfigFixture(t *testing.T) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc(apiPath("/containers/web/json"), func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{
+			"Id": "abc",
+			"Name": "/web",
+			"Config": {
+				"Image": "nginx:1.25",
+				"Entrypoint": ["/docker-entrypoint.sh", "--verbose"],
+				"Cmd": ["nginx", "-g", "daemon off;"],
+				"Env": ["MODE=prod", "PATH=/usr/bin"],
+				"Labels": {"com.docker.compose.project": "shop", "com.docker.compose.service": "web"},
+				"Tty": true,
+				"OpenStdin": true,
+				"User": "nginx",
+				"StopSignal": "SIGQUIT",
+				"Healthcheck": {"Test": ["CMD-SHELL", "curl -f http://localhost/"], "Interval": 30000000000, "Retries": 3},
+				"WorkingDir": "/usr/share/nginx"
+			},
+			"HostConfig": {
+				"NetworkMode": "shop_default",
+				"RestartPolicy": {"Name": "on-failure", "MaximumRetryCount": 3},
+				"Memory": 268435456,
+				"MemoryReservation": 134217728,
+				"ReadonlyRootfs": true,
+				"Init": true,
+				"Ulimits": [{"Name": "nofile", "Soft": 1024, "Hard": 4096}],
+				"Tmpfs": {"/tmp": "", "/var/run/nginx": "size=1m"},
+				"NanoCpus": 1500000000,
+				"PortBindings": {
+					"80/tcp": [{"HostIp": "", "HostPort": "8080"}],
+					"443/tcp": [{"HostIp": "127.0.0.1", "HostPort": "8443"}, {"HostIp": "::1", "HostPort": "8443"}]
+				}
+			},
+			"Mounts": [
+				{"Type": "bind", "Source": "/srv/site", "Destination": "/usr/share/nginx/html", "RW": false},
+				{"Type": "volume", "Name": "cache", "Source": "/var/lib/docker/volumes/cache/_data", "Destination": "/var/cache/nginx", "RW": true},
+				{"Type": "volume", "Name": "0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef", "Destination": "/tmp/anon", "RW": true},
+				{"Type": "tmpfs", "Destination": "/run", "RW": true}
+			]
+		}`))
+	})
+	return mux
+}
+
+func TestDockerCommandSnapshotRunConfig(t *testing.T) {
+	dockerCommand := newFakeDockerCommand(t, runConfigFixture(t))
+
+	runConfig, err := dockerCommand.SnapshotRunConfig("web")
+	assert.NoError(t, err)
+	assert.EqualValues(t, &RunConfig{
+		Name:              "web",
+		Image:             "nginx:1.25",
+		Entrypoint:        []string{"/docker-entrypoint.sh", "--verbose"},
+		Cmd:               []string{"nginx", "-g", "daemon off;"},
+		Env:               []string{"MODE=prod", "PATH=/usr/bin"},
+		Ports:             []string{"127.0.0.1:8443:443/tcp", "8080:80/tcp", "[::1]:8443:443/tcp"},
+		Mounts:            []string{"/srv/site:/usr/share/nginx/html:ro", "cache:/var/cache/nginx"},
+		Network:           "shop_default",
+		Labels:            map[string]string{"com.docker.compose.project": "shop", "com.docker.compose.service": "web"},
+		WorkingDir:        "/usr/share/nginx",
+		RestartPolicy:     "on-failure:3",
+		Tty:               true,
+		Interactive:       true,
+		User:              "nginx",
+		ReadOnly:          true,
+		Tmpfs:             []string{"/tmp", "/var/run/nginx:size=1m"},
+		Ulimits:           []string{"nofile=1024:4096"},
+		Init:              true,
+		StopSignal:        "SIGQUIT",
+		HealthCmd:         "curl -f http://localhost/",
+		HealthInterval:    30 * time.Second,
+		HealthRetries:     3,
+		Memory:            268435456,
+		MemoryReservation: 134217728,
+		NanoCPUs:          1500000000,
+	}, runConfig)
+
+	assert.EqualValues(t, []string{
+		"run", "--detach",
+		"--name", "web",
+		"--env", "MODE=prod",
+		"--env", "PATH=/usr/bin",
+		"--publish", "127.0.0.1:8443:443/tcp",
+		"--publish", "8080:80/tcp",
+		"--publish", "[::1]:8443:443/tcp",
+		"--volume", "/srv/site:/usr/share/nginx/html:ro",
+		"--volume", "cache:/var/cache/nginx",
+		"--network", "shop_default",
+		"--workdir", "/usr/share/nginx",
+		"--restart", "on-failure:3",
+		"--tty",
+		"--interactive",
+		"--user", "nginx",
+		"--read-only",
+		"--tmpfs", "/tmp",
+		"--tmpfs", "/var/run/nginx:size=1m",
+		"--ulimit", "nofile=1024:4096",
+		"--init",
+		"--stop-signal", "SIGQUIT",
+		"--health-cmd", "curl -f http://localhost/",
+		"--health-interval", "30s",
+		"--health-retries", "3",
+		"--label", "com.docker.compose.project=shop",
+		"--label", "com.docker.compose.service=web",
+		"--memory", "268435456",
+		"--memory-reservation", "134217728",
+		"--cpus", "1.5",
+		"--entrypoint", "/docker-entrypoint.sh",
+		"nginx:1.25", "--verbose", "nginx", "-g", "daemon off;",
+	}, runConfig.Args())
+}
+
+func TestDockerCommandReproduceRunCommand(t *testing.T) {
+	dockerCommand := newFakeDockerCommand(t, runConfigFixture(t))
+
+	command, err := dockerCommand.ReproduceRunCommand("web")
+	assert.NoError(t, err)
+	assert.EqualValues(t, `docker run --detach --name web --env MODE=prod --env PATH=/usr/bin `+
+		`--publish 127.0.0.1:8443:443/tcp --publish 8080:80/tcp --publish '[::1]:8443:443/tcp' `+
+		`--volume /srv/site:/usr/share/nginx/html:ro --volume cache:/var/cache/nginx `+
+		`--network shop_default --workdir /usr/share/nginx --restart on-failure:3 --tty --interactive `+
+		`--user nginx --read-only --tmpfs /tmp --tmpfs /var/run/nginx:size=1m --ulimit nofile=1024:4096 --init `+
+		`--stop-signal SIGQUIT --health-cmd 'curl -f http://localhost/' --health-interval 30s --health-retries 3 `+
+		`--label com.docker.compose.project=shop --label com.docker.compose.service=web `+
+		`--memory 268435456 --memory-reservation 134217728 --cpus 1.5 `+
+		`--entrypoint /docker-entrypoint.sh nginx:1.25 --verbose nginx -g 'daemon off;'`, command)
+}
+
+func TestDockerCommandReproduceRunCommandQuotesEnv(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc(apiPath("/containers/api/json"), func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"Id": "def", "Name": "/api", "Config": {"Image": "api", "Env": ["TOKEN=$abc!it's"]}}`))
+	})
+	dockerCommand := newFakeDockerCommand(t, mux)
+
+	command, err := dockerCommand.ReproduceRunCommand("api")
+	assert.NoError(t, err)
+	assert.EqualValues(t, `docker run --detach --name api --env 'TOKEN=$abc!it'\''s' api`, command)
+}
+
+func TestShellQuote(t *testing.T) {
+	type scenario struct {
+		arg      string
+		expected string
+	}
+
+	scenarios := []scenario{
+		{"nginx:1.25", "nginx:1.25"},
+		{"daemon off;", `'daemon off;'`},
+		// none of these should be expanded by the shell
+		{"PASSWORD=$ecret!", `'PASSWORD=$ecret!'`},
+		{"GREETING=it's", `'GREETING=it'\''s'`},
+		{"", `''`},
+	}
+
+	for _, s := range scenarios {
+		assert.EqualValues(t, s.expected, shellQuote(s.arg), s.arg)
+	}
+
+	// round trip through a real shell to make sure it gets back what we quoted
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("no sh available")
+	}
+	value := `PASSWORD=$ecret!'\`
+	output, err := exec.Command("sh", "-c", "printf %s "+shellQuote(value)).Output()
+	assert.NoError(t, err)
+	assert.EqualValues(t, value, string(output))
+}
+
+func TestRunConfigValidate(t *testing.T) {
+	labelFile := filepath.Join(t.TempDir(), "labels")
+	assert.NoError(t, os.WriteFile(labelFile, []byte("team=shop\n"), 0o644))
+	missingLabelFile := filepath.Join(t.TempDir(), "missing")
+
+	type scenario struct {
+		testName  string
+		runConfig RunConfig
+		expected  string
+	}
+
+	scenarios := []scenario{
+		{
+			testName:  "no working dir",
+			runConfig: RunConfig{Image: "nginx"},
+		},
+		{
+			testName:  "absolute working dir",
+			runConfig: RunConfig{Image: "nginx", WorkingDir: "/srv/app"},
+		},
+		{
+			testName:  "relative working dir",
+			runConfig: RunConfig{Image: "nginx", WorkingDir: "srv/app"},
+			expected:  `working dir "srv/app" must be an absolute path`,
+		},
+		{
+			testName:  "memory reservation below the limit",
+			runConfig: RunConfig{Image: "nginx", Memory: 512, MemoryReservation: 256},
+		},
+		{
+			testName:  "memory reservation equal to the limit",
+			runConfig: RunConfig{Image: "nginx", Memory: 512, MemoryReservation: 512},
+		},
+		{
+			testName:  "memory reservation without a limit",
+			runConfig: RunConfig{Image: "nginx", MemoryReservation: 256},
+		},
+		{
+			testName:  "readable label file",
+			runConfig: RunConfig{Image: "nginx", LabelFile: labelFile},
+		},
+		{
+			testName:  "missing label file",
+			runConfig: RunConfig{Image: "nginx", LabelFile: missingLabelFile},
+			expected:  "could not read label file: open " + missingLabelFile + ": no such file or directory",
+		},
+		{
+			testName:  "restart policy",
+			runConfig: RunConfig{Image: "nginx", RestartPolicy: "on-failure:3"},
+		},
+		{
+			testName:  "unknown restart policy",
+			runConfig: RunConfig{Image: "nginx", RestartPolicy: "sometimes"},
+			expected:  `invalid restart policy "sometimes", expected no, always, unless-stopped or on-failure[:max-retries]`,
+		},
+		{
+			testName:  "user",
+			runConfig: RunConfig{Image: "nginx", User: "www-data:www-data"},
+		},
+		{
+			testName:  "user with a space",
+			runConfig: RunConfig{Image: "nginx", User: "www data"},
+			expected:  `invalid user "www data", expected name|uid[:group|gid]`,
+		},
+		{
+			testName:  "user with too many parts",
+			runConfig: RunConfig{Image: "nginx", User: "1000:1000:1000"},
+			expected:  `invalid user "1000:1000:1000", expected name|uid[:group|gid]`,
+		},
+		{
+			testName:  "negative health retries",
+			runConfig: RunConfig{Image: "nginx", HealthCmd: "true", HealthRetries: -1},
+			expected:  "health interval and retries can't be negative",
+		},
+		{
+			testName:  "stop signal by name",
+			runConfig: RunConfig{Image: "nginx", StopSignal: "SIGQUIT"},
+		},
+		{
+			testName:  "stop signal without the SIG prefix",
+			runConfig: RunConfig{Image: "nginx", StopSignal: "RTMIN+3"},
+		},
+		{
+			testName:  "stop signal by number",
+			runConfig: RunConfig{Image: "nginx", StopSignal: "3"},
+		},
+		{
+			testName:  "malformed stop signal",
+			runConfig: RunConfig{Image: "nginx", StopSignal: "sig quit"},
+			expected:  `invalid stop signal "sig quit", expected e.g. SIGTERM or 15`,
+		},
+		{
+			testName:  "tmpfs",
+			runConfig: RunConfig{Image: "nginx", ReadOnly: true, Tmpfs: []string{"/tmp:size=64m"}},
+		},
+		{
+			testName:  "relative tmpfs destination",
+			runConfig: RunConfig{Image: "nginx", Tmpfs: []string{"tmp:size=64m"}},
+			expected:  `tmpfs destination "tmp" must be an absolute path`,
+		},
+		{
+			testName:  "ulimits",
+			runConfig: RunConfig{Image: "nginx", Ulimits: []string{"nofile=1024:4096", "nproc=512", "core=0:-1", "memlock=-1:-1"}},
+		},
+		{
+			testName:  "malformed ulimit",
+			runConfig: RunConfig{Image: "nginx", Ulimits: []string{"nofile:1024"}},
+			expected:  `invalid ulimit "nofile:1024", expected name=soft[:hard]`,
+		},
+		{
+			testName:  "ulimit with a soft limit above the hard limit",
+			runConfig: RunConfig{Image: "nginx", Ulimits: []string{"nofile=4096:1024"}},
+			expected:  `invalid ulimit "nofile=4096:1024", the soft limit can't exceed the hard limit`,
+		},
+		{
+			testName:  "labels",
+			runConfig: RunConfig{Image: "nginx", Labels: map[string]string{"team": "shop", "empty": ""}},
+		},
+		{
+			testName:  "label key with an equals sign",
+			runConfig: RunConfig{Image: "nginx", Labels: map[string]string{"team=shop": "yes"}},
+			expected:  `invalid label key "team=shop"`,
+		},
+		{
+			testName:  "empty label key",
+			runConfig: RunConfig{Image: "nginx", Labels: map[string]string{"": "shop"}},
+			expected:  `invalid label key ""`,
+		},
+		{
+			testName:  "memory reservation above the limit",
+			runConfig: RunConfig{Image: "nginx", Memory: 256, MemoryReservation: 512},
+			expected:  "memory reservation (512 bytes) can't exceed the memory limit (256 bytes)",
+		},
+	}
+
+	for _, s := range scenarios {
+		t.Run(s.testName, func(t *testing.T) {
+			err := s.runConfig.Validate()
+			if s.expected == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.EqualError(t, err, s.expected)
+			}
+		})
+	}
+}
+
+func TestRunConfigArgs(t *testing.T) {
+	type scenario struct {
+		testName  string
+		runConfig RunConfig
+		expected  []string
+	}
+
+	scenarios := []scenario{
+		{
+			testName:  "uid and gid",
+			runConfig: RunConfig{Image: "nginx", User: "1000:1000"},
+			expected:  []string{"run", "--detach", "--user", "1000:1000", "nginx"},
+		},
+		{
+			testName:  "username",
+			runConfig: RunConfig{Image: "nginx", User: "www-data"},
+			expected:  []string{"run", "--detach", "--user", "www-data", "nginx"},
+		},
+		{
+			testName:  "full healthcheck",
+			runConfig: RunConfig{Image: "nginx", HealthCmd: "curl -f http://localhost/", HealthInterval: 30 * time.Second, HealthRetries: 3},
+			expected:  []string{"run", "--detach", "--health-cmd", "curl -f http://localhost/", "--health-interval", "30s", "--health-retries", "3", "nginx"},
+		},
+		{
+			// unset fields are left to docker's defaults
+			testName:  "partial healthcheck",
+			runConfig: RunConfig{Image: "nginx", HealthCmd: "curl -f http://localhost/"},
+			expected:  []string{"run", "--detach", "--health-cmd", "curl -f http://localhost/", "nginx"},
+		},
+		{
+			testName:  "stop signal",
+			runConfig: RunConfig{Image: "nginx", StopSignal: "SIGQUIT"},
+			expected:  []string{"run", "--detach", "--stop-signal", "SIGQUIT", "nginx"},
+		},
+		{
+			testName:  "read-only",
+			runConfig: RunConfig{Image: "nginx", ReadOnly: true},
+			expected:  []string{"run", "--detach", "--read-only", "nginx"},
+		},
+		{
+			testName:  "read-only with tmpfs",
+			runConfig: RunConfig{Image: "nginx", ReadOnly: true, Tmpfs: []string{"/tmp", "/var/cache/nginx:size=64m"}},
+			expected:  []string{"run", "--detach", "--read-only", "--tmpfs", "/tmp", "--tmpfs", "/var/cache/nginx:size=64m", "nginx"},
+		},
+		{
+			testName:  "init",
+			runConfig: RunConfig{Image: "nginx", Init: true},
+			expected:  []string{"run", "--detach", "--init", "nginx"},
+		},
+		{
+			testName:  "no init",
+			runConfig: RunConfig{Image: "nginx", Init: false},
+			expected:  []string{"run", "--detach", "nginx"},
+		},
+		{
+			testName:  "ulimits",
+			runConfig: RunConfig{Image: "nginx", Ulimits: []string{"nofile=1024:4096", "nproc=512"}},
+			expected:  []string{"run", "--detach", "--ulimit", "nofile=1024:4096", "--ulimit", "nproc=512", "nginx"},
+		},
+		{
+			testName:  "single entrypoint",
+			runConfig: RunConfig{Image: "nginx", Entrypoint: []string{"/bin/sh"}, Cmd: []string{"-c", "env"}},
+			expected:  []string{"run", "--detach", "--entrypoint", "/bin/sh", "nginx", "-c", "env"},
+		},
+		{
+			testName:  "label file",
+			runConfig: RunConfig{Image: "nginx", LabelFile: "/etc/shop/labels"},
+			expected:  []string{"run", "--detach", "--label-file", "/etc/shop/labels", "nginx"},
+		},
+		{
+			// each label is its own arg so that values with spaces survive
+			testName: "labels",
+			runConfig: RunConfig{Image: "nginx", Labels: map[string]string{
+				"team":        "shop",
+				"description": "serves the shop front",
+			}},
+			expected: []string{"run", "--detach", "--label", "description=serves the shop front", "--label", "team=shop", "nginx"},
+		},
+	}
+
+	for _, s := range scenarios {
+		t.Run(s.testName, func(t *testing.T) {
+			assert.EqualValues(t, s.expected, s.runConfig.Args())
+		})
+	}
+}
@@ -0,0 +1,100 @@
+package commands
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+
+	"github.com/go-errors/errors"
+)
+
+// runTemplatesFile is where saved run templates live, within the config dir
+const runTemplatesFile = "run_templates.json"
+
+var runTemplateNameRegexp = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_.-]*$`)
+
+func (c *DockerCommand) runTemplatesPath() string {
+	return filepath.Join(c.Config.ConfigDir, runTemplatesFile)
+}
+
+// loadRunTemplates reads all our saved templates, treating a missing store as
+// an empty one
+func (c *DockerCommand) loadRunTemplates() (map[string]RunConfig, error) {
+	content, err := os.ReadFile(c.runTemplatesPath())
+	if os.IsNotExist(err) {
+		return map[string]RunConfig{}, nil
+	}
+	if err != nil {
+		return nil, WrapError(err)
+	}
+
+	templates := map[string]RunConfig{}
+	if err := json.Unmarshal(content, &templates); err != nil {
+		return nil, errors.Errorf("could not parse %s: %v", c.runTemplatesPath(), err)
+	}
+
+	return templates, nil
+}
+
+// SaveRunTemplate saves the given run config under the given name so that it
+// can be quickly relaunched later, replacing any template with the same name.
+// Names may contain letters, digits, '_', '.' and '-'.
+func (c *DockerCommand) SaveRunTemplate(name string, cfg RunConfig) error {
+	if !runTemplateNameRegexp.MatchString(name) {
+		return errors.Errorf("invalid run template name %q", name)
+	}
+	if err := cfg.Validate(); err != nil {
+		return err
+	}
+
+	templates, err := c.loadRunTemplates()
+	if err != nil {
+		return err
+	}
+	templates[name] = cfg
+
+	content, err := json.MarshalIndent(templates, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(c.Config.ConfigDir, 0o755); err != nil {
+		return WrapError(err)
+	}
+
+	// templates can hold env vars, which often contain secrets
+	return WrapError(os.WriteFile(c.runTemplatesPath(), content, 0o600))
+}
+
+// LoadRunTemplate returns the run template saved under the given name
+func (c *DockerCommand) LoadRunTemplate(name string) (*RunConfig, error) {
+	templates, err := c.loadRunTemplates()
+	if err != nil {
+		return nil, err
+	}
+
+	cfg, ok := templates[name]
+	if !ok {
+		return nil, errors.Errorf("no run template named %q", name)
+	}
+
+	return &cfg, nil
+}
+
+// ListRunTemplates returns the names of all saved run templates, sorted
+func (c *DockerCommand) ListRunTemplates() ([]string, error) {
+	templates, err := c.loadRunTemplates()
+	if err != nil {
+		return nil, err
+	}
+
+	names := make([]string, 0, len(templates))
+	for name := range templates {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names, nil
+}
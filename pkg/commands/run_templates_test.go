@@ -0,0 +1,65 @@
+package commands
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDockerCommandRunTemplates(t *testing.T) {
+	dockerCommand := NewDummyDockerCommand()
+	dockerCommand.Config.ConfigDir = filepath.Join(t.TempDir(), "lazydocker")
+
+	// nothing has been saved yet, so there's no store file
+	names, err := dockerCommand.ListRunTemplates()
+	assert.NoError(t, err)
+	assert.EqualValues(t, []string{}, names)
+
+	_, err = dockerCommand.LoadRunTemplate("web")
+	assert.EqualError(t, err, `no run template named "web"`)
+
+	web := RunConfig{Image: "nginx:1.25", Ports: []string{"8080:80/tcp"}, Env: []string{"MODE=dev"}}
+	assert.NoError(t, dockerCommand.SaveRunTemplate("web", web))
+	assert.NoError(t, dockerCommand.SaveRunTemplate("db.local", RunConfig{Image: "postgres:16"}))
+
+	loaded, err := dockerCommand.LoadRunTemplate("web")
+	assert.NoError(t, err)
+	assert.EqualValues(t, &web, loaded)
+
+	names, err = dockerCommand.ListRunTemplates()
+	assert.NoError(t, err)
+	assert.EqualValues(t, []string{"db.local", "web"}, names)
+
+	// saving under an existing name replaces the template
+	assert.NoError(t, dockerCommand.SaveRunTemplate("web", RunConfig{Image: "nginx:1.27"}))
+	loaded, err = dockerCommand.LoadRunTemplate("web")
+	assert.NoError(t, err)
+	assert.EqualValues(t, "nginx:1.27", loaded.Image)
+
+	info, err := os.Stat(filepath.Join(dockerCommand.Config.ConfigDir, runTemplatesFile))
+	assert.NoError(t, err)
+	assert.EqualValues(t, os.FileMode(0o600), info.Mode().Perm())
+}
+
+func TestDockerCommandSaveRunTemplateInvalidName(t *testing.T) {
+	dockerCommand := NewDummyDockerCommand()
+	dockerCommand.Config.ConfigDir = t.TempDir()
+
+	for _, name := range []string{"", "../escape", "has space", "-leading"} {
+		assert.EqualError(t, dockerCommand.SaveRunTemplate(name, RunConfig{}), `invalid run template name "`+name+`"`)
+	}
+}
+
+func TestDockerCommandSaveRunTemplateInvalidConfig(t *testing.T) {
+	dockerCommand := NewDummyDockerCommand()
+	dockerCommand.Config.ConfigDir = t.TempDir()
+
+	err := dockerCommand.SaveRunTemplate("web", RunConfig{Image: "nginx", WorkingDir: "app"})
+	assert.EqualError(t, err, `working dir "app" must be an absolute path`)
+
+	names, err := dockerCommand.ListRunTemplates()
+	assert.NoError(t, err)
+	assert.Empty(t, names)
+}
@@ -0,0 +1,193 @@
+package commands
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"time"
+
+	dockerTypes "github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/errdefs"
+	"github.com/docker/go-units"
+	"github.com/go-errors/errors"
+	"github.com/jesseduffield/lazydocker/pkg/utils"
+	"github.com/samber/lo"
+)
+
+// DataRoot returns the directory the docker daemon stores its data in (images,
+// containers, volumes etc), which is handy for working out where your disk
+// space has gone
+func (c *DockerCommand) DataRoot() (string, error) {
+	info, err := c.Client.Info(context.Background())
+	if err != nil {
+		return "", err
+	}
+
+	if info.DockerRootDir == "" {
+		return "", errors.New("docker daemon did not report its data root")
+	}
+
+	return info.DockerRootDir, nil
+}
+
+// CheckDiskSpace tells us whether the filesystem holding docker's data root has
+// at least the given number of bytes free, so that we can warn before a large
+// pull or build. We stat the data root on this machine, so this only works when
+// the daemon is local; otherwise we return an error rather than risk reporting
+// on this machine's docker install when the daemon is elsewhere.
+func (c *DockerCommand) CheckDiskSpace(requiredBytes int64) (bool, error) {
+	if err := c.requireLocalDaemon("checking free disk space"); err != nil {
+		return false, err
+	}
+
+	dataRoot, err := c.DataRoot()
+	if err != nil {
+		return false, err
+	}
+
+	free, err := freeDiskSpace(dataRoot)
+	if err != nil {
+		return false, WrapError(err)
+	}
+
+	return free >= requiredBytes, nil
+}
+
+// DefaultIsolation returns how the daemon isolates containers by default. On
+// Windows that's its isolation mode ("process" or "hyperv"). Other platforms
+// don't report one, so there we return the default OCI runtime instead (e.g.
+// "runc"), which is what determines isolation there.
+func (c *DockerCommand) DefaultIsolation() (string, error) {
+	info, err := c.Client.Info(context.Background())
+	if err != nil {
+		return "", err
+	}
+
+	if info.Isolation != "" {
+		return string(info.Isolation), nil
+	}
+	if info.DefaultRuntime != "" {
+		return info.DefaultRuntime, nil
+	}
+
+	return "", errors.New("docker daemon did not report its default isolation or runtime")
+}
+
+// BuildCacheInfo tells us how much disk space the build cache is using
+type BuildCacheInfo struct {
+	TotalBytes       int64
+	ReclaimableBytes int64
+}
+
+// diskUsageRow is one line of `docker system df --format '{{json .}}'`. Sizes
+// are human-readable e.g. "1.2GB", and reclaimable sizes may have a percentage
+// on the end e.g. "1.2GB (50%)"
+type diskUsageRow struct {
+	Type        string `json:"Type"`
+	Size        string `json:"Size"`
+	Reclaimable string `json:"Reclaimable"`
+}
+
+const buildCacheDiskUsageType = "Build Cache"
+
+// parseBuildCacheDiskUsage picks the build cache row out of the disk usage
+// output, returning nil if there isn't one
+func parseBuildCacheDiskUsage(output string) (*BuildCacheInfo, error) {
+	for _, line := range utils.SplitLines(output) {
+		var row diskUsageRow
+		if err := json.Unmarshal([]byte(line), &row); err != nil {
+			return nil, err
+		}
+		if row.Type != buildCacheDiskUsageType {
+			continue
+		}
+
+		total, err := units.FromHumanSize(row.Size)
+		if err != nil {
+			return nil, err
+		}
+		reclaimableStr, _, _ := strings.Cut(row.Reclaimable, " ")
+		reclaimable, err := units.FromHumanSize(reclaimableStr)
+		if err != nil {
+			return nil, err
+		}
+
+		return &BuildCacheInfo{TotalBytes: total, ReclaimableBytes: reclaimable}, nil
+	}
+
+	return nil, nil
+}
+
+// GetBuildCache returns how much space the build cache is taking up. We go
+// through the CLI because our API version predates build cache reporting.
+// Returns nil with no error if the daemon doesn't report a build cache.
+func (c *DockerCommand) GetBuildCache() (*BuildCacheInfo, error) {
+	cmd := c.OSCommand.NewCmd("docker", "system", "df", "--format", "{{json .}}")
+	output, err := sanitisedCommandOutput(cmd.Output())
+	if err != nil {
+		return nil, err
+	}
+
+	return parseBuildCacheDiskUsage(output)
+}
+
+// PruneBuildCache removes all unused build cache, returning docker's summary of
+// what was reclaimed
+func (c *DockerCommand) PruneBuildCache() (string, error) {
+	cmd := c.OSCommand.NewCmd("docker", "builder", "prune", "--force")
+	return sanitisedCommandOutput(cmd.Output())
+}
+
+// SystemStats is the combined resource usage of all running containers at a
+// point in time
+type SystemStats struct {
+	Containers    int
+	CPUPercentage float64
+	// MemoryUsage is in bytes
+	MemoryUsage int
+	RecordedAt  time.Time
+}
+
+// systemStats takes a single stats sample from each running container and adds
+// them up. Containers we can't sample (e.g. because they stopped while we were
+// sampling) are skipped, so one bad container doesn't hide the rest. The samples
+// are fetched a few at a time, so this takes about a second per
+// statsSampleConcurrency containers.
+func (c *DockerCommand) systemStats(ctx context.Context) (SystemStats, error) {
+	containers, err := c.Client.ContainerList(ctx, container.ListOptions{})
+	if err != nil {
+		return SystemStats{}, err
+	}
+
+	ids := lo.Map(containers, func(ctr dockerTypes.Container, _ int) string { return ctr.ID })
+	samples, errs := c.containerStatsSamples(ctx, ids)
+
+	result := SystemStats{}
+	for i, stats := range samples {
+		if err := errs[i]; err != nil {
+			// a container stopping mid-sample is expected, anything else is
+			// worth knowing about
+			if !errdefs.IsNotFound(err) && !errdefs.IsConflict(err) {
+				c.Log.Error(err)
+			}
+			continue
+		}
+
+		result.Containers++
+		result.CPUPercentage += stats.CalculateContainerCPUPercentage()
+		result.MemoryUsage += stats.MemoryStats.Usage
+	}
+	result.RecordedAt = time.Now()
+
+	return result, nil
+}
+
+// StreamSystemStats samples the combined resource usage of all running
+// containers at the given interval, for a system-wide gauge. The first sample
+// is taken straight away, and if it fails we return its error. A later sample
+// failing (e.g. because the daemon briefly went away) is logged and skipped,
+// and the channel is only closed once the context is done.
+func (c *DockerCommand) StreamSystemStats(ctx context.Context, interval time.Duration) (<-chan SystemStats, error) {
+	return pollStream(ctx, c.Log, interval, false, c.systemStats)
+}
@@ -0,0 +1,212 @@
+package commands
+
+import (
+	"context"
+	"math"
+	"net/http"
+	"os/exec"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	dockerTypes "github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/system"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDockerCommandDataRoot(t *testing.T) {
+	info := system.Info{DockerRootDir: "/var/lib/docker"}
+	mux := http.NewServeMux()
+	mux.HandleFunc(apiPath("/info"), func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(t, w, info)
+	})
+	dockerCommand := newFakeDockerCommand(t, mux)
+
+	dataRoot, err := dockerCommand.DataRoot()
+	assert.NoError(t, err)
+	assert.EqualValues(t, "/var/lib/docker", dataRoot)
+
+	info = system.Info{}
+	_, err = dockerCommand.DataRoot()
+	assert.EqualError(t, err, "docker daemon did not report its data root")
+}
+
+func TestDockerCommandDefaultIsolation(t *testing.T) {
+	type scenario struct {
+		info        string
+		expected    string
+		expectedErr string
+	}
+
+	scenarios := []scenario{
+		{`{"OSType": "windows", "Isolation": "hyperv", "DefaultRuntime": ""}`, "hyperv", ""},
+		{`{"OSType": "linux", "DefaultRuntime": "runc", "Runtimes": {"runc": {"path": "runc"}}}`, "runc", ""},
+		{`{"OSType": "linux"}`, "", "docker daemon did not report its default isolation or runtime"},
+	}
+
+	for _, s := range scenarios {
+		info := s.info
+		mux := http.NewServeMux()
+		mux.HandleFunc(apiPath("/info"), func(w http.ResponseWriter, r *http.Request) {
+			_, _ = w.Write([]byte(info))
+		})
+
+		isolation, err := newFakeDockerCommand(t, mux).DefaultIsolation()
+		if s.expectedErr != "" {
+			assert.EqualError(t, err, s.expectedErr)
+			continue
+		}
+		assert.NoError(t, err)
+		assert.EqualValues(t, s.expected, isolation)
+	}
+}
+
+func TestParseBuildCacheDiskUsage(t *testing.T) {
+	type scenario struct {
+		output string
+		test   func(*BuildCacheInfo, error)
+	}
+
+	scenarios := []scenario{
+		{
+			`{"Active":"3","Reclaimable":"2.1GB (72%)","Size":"2.9GB","TotalCount":"12","Type":"Images"}
+{"Active":"1","Reclaimable":"0B (0%)","Size":"12kB","TotalCount":"1","Type":"Containers"}
+{"Active":"0","Reclaimable":"1.5GB","Size":"2GB","TotalCount":"40","Type":"Build Cache"}
+`,
+			func(info *BuildCacheInfo, err error) {
+				assert.NoError(t, err)
+				assert.EqualValues(t, &BuildCacheInfo{TotalBytes: 2000000000, ReclaimableBytes: 1500000000}, info)
+			},
+		},
+		{
+			`{"Active":"2","Reclaimable":"12MB (50%)","Size":"24MB","TotalCount":"4","Type":"Local Volumes"}`,
+			func(info *BuildCacheInfo, err error) {
+				assert.NoError(t, err)
+				assert.Nil(t, info)
+			},
+		},
+		{
+			`{"Active":"0","Reclaimable":"lots","Size":"2GB","TotalCount":"40","Type":"Build Cache"}`,
+			func(info *BuildCacheInfo, err error) {
+				assert.Error(t, err)
+			},
+		},
+	}
+
+	for _, s := range scenarios {
+		s.test(parseBuildCacheDiskUsage(s.output))
+	}
+}
+
+func TestDockerCommandGetBuildCache(t *testing.T) {
+	osCommand := NewDummyOSCommand()
+	osCommand.SetCommand(func(name string, args ...string) *exec.Cmd {
+		assert.EqualValues(t, []string{"system", "df", "--format", "{{json .}}"}, args)
+		return exec.Command("echo", `{"Reclaimable":"0B","Size":"1.024kB","Type":"Build Cache"}`)
+	})
+
+	info, err := NewDummyDockerCommandWithOSCommand(osCommand).GetBuildCache()
+	assert.NoError(t, err)
+	assert.EqualValues(t, &BuildCacheInfo{TotalBytes: 1024, ReclaimableBytes: 0}, info)
+}
+
+func TestDockerCommandStreamSystemStats(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc(apiPath("/containers/json"), func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(t, w, []dockerTypes.Container{{ID: "web"}, {ID: "db"}})
+	})
+	stats := map[string]string{
+		"web": `{"cpu_stats":{"cpu_usage":{"total_usage":300},"system_cpu_usage":2000},"precpu_stats":{"cpu_usage":{"total_usage":100},"system_cpu_usage":1000},"memory_stats":{"usage":1024}}`,
+		"db":  `{"cpu_stats":{"cpu_usage":{"total_usage":150},"system_cpu_usage":2000},"precpu_stats":{"cpu_usage":{"total_usage":100},"system_cpu_usage":1000},"memory_stats":{"usage":2048}}`,
+	}
+	for id, body := range stats {
+		body := body
+		mux.HandleFunc(apiPath("/containers/"+id+"/stats"), func(w http.ResponseWriter, r *http.Request) {
+			assert.EqualValues(t, "0", r.URL.Query().Get("stream"))
+			_, _ = w.Write([]byte(body))
+		})
+	}
+	dockerCommand := newFakeDockerCommand(t, mux)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	results, err := dockerCommand.StreamSystemStats(ctx, time.Millisecond)
+	assert.NoError(t, err)
+
+	for i := 0; i < 2; i++ {
+		sample := <-results
+		assert.EqualValues(t, 2, sample.Containers)
+		assert.InDelta(t, 25.0, sample.CPUPercentage, 0.001)
+		assert.EqualValues(t, 3072, sample.MemoryUsage)
+	}
+
+	cancel()
+	for range results {
+	}
+}
+
+func TestDockerCommandStreamSystemStatsSkipsFailures(t *testing.T) {
+	var listCalls int32
+	mux := http.NewServeMux()
+	mux.HandleFunc(apiPath("/containers/json"), func(w http.ResponseWriter, r *http.Request) {
+		// the daemon blips on the second tick
+		if atomic.AddInt32(&listCalls, 1) == 2 {
+			http.Error(w, "daemon unavailable", http.StatusInternalServerError)
+			return
+		}
+		writeJSON(t, w, []dockerTypes.Container{{ID: "web"}, {ID: "broken"}})
+	})
+	mux.HandleFunc(apiPath("/containers/web/stats"), func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"cpu_stats":{"cpu_usage":{"total_usage":300},"system_cpu_usage":2000},"precpu_stats":{"cpu_usage":{"total_usage":100},"system_cpu_usage":1000},"memory_stats":{"usage":1024}}`))
+	})
+	mux.HandleFunc(apiPath("/containers/broken/stats"), func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "cgroup read failed", http.StatusInternalServerError)
+	})
+	dockerCommand := newFakeDockerCommand(t, mux)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	results, err := dockerCommand.StreamSystemStats(ctx, time.Millisecond)
+	assert.NoError(t, err)
+
+	// the first sample comes before the blip and the second after it
+	for i := 0; i < 2; i++ {
+		sample, ok := <-results
+		assert.True(t, ok)
+		assert.EqualValues(t, 1, sample.Containers)
+		assert.InDelta(t, 20.0, sample.CPUPercentage, 0.001)
+		assert.EqualValues(t, 1024, sample.MemoryUsage)
+	}
+	assert.GreaterOrEqual(t, atomic.LoadInt32(&listCalls), int32(3))
+
+	cancel()
+	for range results {
+	}
+}
+
+func TestDockerCommandCheckDiskSpace(t *testing.T) {
+	info := system.Info{DockerRootDir: t.TempDir()}
+	mux := http.NewServeMux()
+	mux.HandleFunc(apiPath("/info"), func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(t, w, info)
+	})
+	dockerCommand := newFakeLocalDockerCommand(t, mux)
+
+	enough, err := dockerCommand.CheckDiskSpace(1)
+	assert.NoError(t, err)
+	assert.True(t, enough)
+
+	enough, err = dockerCommand.CheckDiskSpace(math.MaxInt64)
+	assert.NoError(t, err)
+	assert.False(t, enough)
+
+	info = system.Info{DockerRootDir: filepath.Join(t.TempDir(), "missing")}
+	_, err = dockerCommand.CheckDiskSpace(1)
+	assert.Error(t, err)
+
+	// a remote daemon's data root might happen to exist here too, but it
+	// wouldn't be the disk the daemon is using
+	info = system.Info{DockerRootDir: t.TempDir()}
+	remoteDockerCommand := newFakeDockerCommand(t, mux)
+	_, err = remoteDockerCommand.CheckDiskSpace(1)
+	assert.EqualError(t, err, "checking free disk space needs a local docker daemon, but docker is at "+remoteDockerCommand.Client.DaemonHost())
+}
@@ -2,8 +2,12 @@ package commands
 
 import (
 	"context"
+	"strings"
 
+	dockerTypes "github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/container"
 	"github.com/docker/docker/api/types/filters"
+	"github.com/docker/docker/api/types/mount"
 	"github.com/docker/docker/api/types/volume"
 	"github.com/docker/docker/client"
 	"github.com/sirupsen/logrus"
@@ -54,3 +58,57 @@ func (c *DockerCommand) PruneVolumes() error {
 func (v *Volume) Remove(force bool) error {
 	return v.Client.VolumeRemove(context.Background(), v.Name, force)
 }
+
+// VolumeMount tells us that a container mounts a volume at a given destination
+type VolumeMount struct {
+	ContainerID   string
+	ContainerName string
+	Destination   string
+	ReadOnly      bool
+}
+
+// VolumeMountpoints returns each container that mounts the given named volume,
+// along with where it's mounted inside the container
+func (c *DockerCommand) VolumeMountpoints(name string) ([]VolumeMount, error) {
+	containers, err := c.Client.ContainerList(context.Background(), container.ListOptions{All: true})
+	if err != nil {
+		return nil, err
+	}
+
+	mounts := []VolumeMount{}
+	for _, ctr := range containers {
+		mounts = append(mounts, volumeMounts(ctr, name)...)
+	}
+
+	return mounts, nil
+}
+
+// VolumeMounts returns where the container mounts the given named volume, if
+// at all. Unlike DockerCommand.VolumeMountpoints this goes off what we already
+// know about the container, so it doesn't need to call docker.
+func (c *Container) VolumeMounts(name string) []VolumeMount {
+	return volumeMounts(c.Container, name)
+}
+
+func volumeMounts(ctr dockerTypes.Container, name string) []VolumeMount {
+	mounts := []VolumeMount{}
+	for _, mountPoint := range ctr.Mounts {
+		if mountPoint.Type != mount.TypeVolume || mountPoint.Name != name {
+			continue
+		}
+
+		containerName := ctr.ID
+		if len(ctr.Names) > 0 {
+			containerName = strings.TrimLeft(ctr.Names[0], "/")
+		}
+
+		mounts = append(mounts, VolumeMount{
+			ContainerID:   ctr.ID,
+			ContainerName: containerName,
+			Destination:   mountPoint.Destination,
+			ReadOnly:      !mountPoint.RW,
+		})
+	}
+
+	return mounts
+}
@@ -0,0 +1,63 @@
+package commands
+
+import (
+	"net/http"
+	"testing"
+
+	dockerTypes "github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/mount"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDockerCommandVolumeMountpoints(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc(apiPath("/containers/json"), func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(t, w, []dockerTypes.Container{
+			{
+				ID:    "aaa",
+				Names: []string{"/web"},
+				Mounts: []dockerTypes.MountPoint{
+					{Type: mount.TypeVolume, Name: "data", Destination: "/var/lib/data", RW: true},
+					{Type: mount.TypeBind, Source: "/tmp", Destination: "/tmp"},
+				},
+			},
+			{
+				ID:    "bbb",
+				Names: []string{"/backup"},
+				Mounts: []dockerTypes.MountPoint{
+					{Type: mount.TypeVolume, Name: "data", Destination: "/backup"},
+				},
+			},
+			{
+				ID:    "ccc",
+				Names: []string{"/other"},
+				Mounts: []dockerTypes.MountPoint{
+					{Type: mount.TypeVolume, Name: "cache", Destination: "/cache", RW: true},
+				},
+			},
+		})
+	})
+
+	mounts, err := newFakeDockerCommand(t, mux).VolumeMountpoints("data")
+	assert.NoError(t, err)
+	assert.EqualValues(t, []VolumeMount{
+		{ContainerID: "aaa", ContainerName: "web", Destination: "/var/lib/data", ReadOnly: false},
+		{ContainerID: "bbb", ContainerName: "backup", Destination: "/backup", ReadOnly: true},
+	}, mounts)
+}
+
+func TestContainerVolumeMounts(t *testing.T) {
+	ctr := &Container{Container: dockerTypes.Container{
+		ID:    "aaa",
+		Names: []string{"/web"},
+		Mounts: []dockerTypes.MountPoint{
+			{Type: mount.TypeVolume, Name: "data", Destination: "/var/lib/data", RW: true},
+			{Type: mount.TypeBind, Source: "/data", Destination: "/data"},
+		},
+	}}
+
+	assert.EqualValues(t, []VolumeMount{
+		{ContainerID: "aaa", ContainerName: "web", Destination: "/var/lib/data", ReadOnly: false},
+	}, ctr.VolumeMounts("data"))
+	assert.Empty(t, ctr.VolumeMounts("cache"))
+}
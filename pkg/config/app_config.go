@@ -60,6 +60,11 @@ type UserConfig struct {
 	// Replacements determines how we render an item's info
 	Replacements Replacements `yaml:"replacements,omitempty"`
 
+	// StopTimeout is how many seconds we give a container to stop before it's
+	// killed, for containers that weren't created with their own --stop-timeout.
+	// If not set, the docker daemon's default (usually 10 seconds) applies
+	StopTimeout *int `yaml:"stopTimeout,omitempty"`
+
 	// For demo purposes: any list item with one of these strings as a substring
 	// will be filtered out and not displayed.
 	// Not documented because it's subject to change
@@ -344,6 +349,16 @@ type LogsConfig struct {
 	Timestamps bool   `yaml:"timestamps,omitempty"`
 	Since      string `yaml:"since,omitempty"`
 	Tail       string `yaml:"tail,omitempty"`
+
+	// BufferInterval batches up log output over the given interval before
+	// writing it to the main panel, so that high-volume logs don't make us
+	// redraw constantly. Zero (the default) writes logs as soon as they arrive.
+	BufferInterval time.Duration `yaml:"bufferInterval,omitempty"`
+
+	// PauseOnScroll holds back new log output while you're scrolled up in the
+	// main panel, so that what you're reading doesn't move. Jumping to the
+	// bottom writes out everything that was held back.
+	PauseOnScroll bool `yaml:"pauseOnScroll,omitempty"`
 }
 
 // GetDefaultConfig returns the application default configuration NOTE (to
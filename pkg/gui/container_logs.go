@@ -136,6 +136,26 @@ func (gui *Gui) writeContainerLogs(ctr *commands.Container, ctx context.Context,
 		}
 	}
 
+	if interval := gui.Config.UserConfig.Logs.BufferInterval; interval > 0 {
+		batchingWriter := utils.NewBatchingWriter(writer, interval)
+		defer batchingWriter.Close()
+		writer = batchingWriter
+	}
+
+	pausableWriter := utils.NewPausableWriter(writer, maxPausedLogLines)
+	gui.setLogsWriter(pausableWriter)
+	defer func() {
+		gui.setLogsWriter(nil)
+		// if the container has exited rather than us having moved on, show
+		// whatever was held back
+		if ctx.Err() == nil {
+			if err := pausableWriter.Resume(); err != nil {
+				gui.Log.Error(err)
+			}
+		}
+	}()
+	writer = pausableWriter
+
 	if ctr.Details.Config.Tty {
 		_, err = io.Copy(writer, readCloser)
 		if err != nil {
@@ -150,3 +170,39 @@ func (gui *Gui) writeContainerLogs(ctr *commands.Container, ctx context.Context,
 
 	return nil
 }
+
+// maxPausedLogLines is how many lines of logs we hold on to while they're
+// paused, after which we start dropping the oldest ones
+const maxPausedLogLines = 10000
+
+func (gui *Gui) setLogsWriter(writer *utils.PausableWriter) {
+	gui.Mutexes.LogsWriterMutex.Lock()
+	defer gui.Mutexes.LogsWriterMutex.Unlock()
+
+	gui.logsWriter = writer
+}
+
+// pauseLogs holds back the output of the logs being streamed, if any, until
+// resumeLogs is called
+func (gui *Gui) pauseLogs() {
+	if !gui.Config.UserConfig.Logs.PauseOnScroll {
+		return
+	}
+
+	gui.Mutexes.LogsWriterMutex.Lock()
+	defer gui.Mutexes.LogsWriterMutex.Unlock()
+
+	if gui.logsWriter != nil {
+		gui.logsWriter.Pause()
+	}
+}
+
+func (gui *Gui) resumeLogs() error {
+	gui.Mutexes.LogsWriterMutex.Lock()
+	defer gui.Mutexes.LogsWriterMutex.Unlock()
+
+	if gui.logsWriter == nil {
+		return nil
+	}
+	return gui.logsWriter.Resume()
+}
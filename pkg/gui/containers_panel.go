@@ -176,13 +176,17 @@ func (gui *Gui) containerConfigStr(container *commands.Container) string {
 	output += "\n"
 
 	output += utils.WithPadding("Mounts: ", padding)
-	if len(container.Details.Mounts) > 0 {
+	if mounts := container.Mounts(); len(mounts) > 0 {
 		output += "\n"
-		for _, mount := range container.Details.Mounts {
+		for _, mount := range mounts {
 			if mount.Type == "volume" {
 				output += fmt.Sprintf("%s%s %s\n", strings.Repeat(" ", padding), utils.ColoredString(string(mount.Type)+":", color.FgYellow), mount.Name)
 			} else {
-				output += fmt.Sprintf("%s%s %s:%s\n", strings.Repeat(" ", padding), utils.ColoredString(string(mount.Type)+":", color.FgYellow), mount.Source, mount.Destination)
+				missing := ""
+				if mount.SourceMissing {
+					missing = " " + utils.ColoredString("(source missing)", color.FgRed)
+				}
+				output += fmt.Sprintf("%s%s %s:%s%s\n", strings.Repeat(" ", padding), utils.ColoredString(string(mount.Type)+":", color.FgYellow), mount.Source, mount.Destination, missing)
 			}
 		}
 	} else {
@@ -375,7 +379,7 @@ func (gui *Gui) handleContainerStop(g *gocui.Gui, v *gocui.View) error {
 
 	return gui.createConfirmationPanel(gui.Tr.Confirm, gui.Tr.StopContainer, func(g *gocui.Gui, v *gocui.View) error {
 		return gui.WithWaitingStatus(gui.Tr.StoppingStatus, func() error {
-			if err := ctr.Stop(); err != nil {
+			if err := ctr.Stop(gui.Config.UserConfig.StopTimeout); err != nil {
 				return gui.createErrorPanel(err.Error())
 			}
 
@@ -457,6 +461,16 @@ func (gui *Gui) containerExecShell(container *commands.Container) error {
 	return gui.runSubprocess(cmd)
 }
 
+// RunForeground runs a throwaway container from the given image in the terminal,
+// returning to lazydocker once it exits
+func (gui *Gui) RunForeground(image string, cmd []string) error {
+	subprocess, err := gui.DockerCommand.RunForegroundCmd(image, cmd)
+	if err != nil {
+		return gui.createErrorPanel(err.Error())
+	}
+	return gui.runSubprocess(subprocess)
+}
+
 func (gui *Gui) handleContainersCustomCommand(g *gocui.Gui, v *gocui.View) error {
 	ctr, err := gui.Panels.Containers.GetSelectedItem()
 	if err != nil {
@@ -476,7 +490,7 @@ func (gui *Gui) handleStopContainers() error {
 	return gui.createConfirmationPanel(gui.Tr.Confirm, gui.Tr.ConfirmStopContainers, func(g *gocui.Gui, v *gocui.View) error {
 		return gui.WithWaitingStatus(gui.Tr.StoppingStatus, func() error {
 			for _, ctr := range gui.Panels.Containers.List.GetAllItems() {
-				if err := ctr.Stop(); err != nil {
+				if err := ctr.Stop(gui.Config.UserConfig.StopTimeout); err != nil {
 					gui.Log.Error(err)
 				}
 			}
@@ -533,19 +547,10 @@ func (gui *Gui) handleContainersOpenInBrowserCommand(g *gocui.Gui, v *gocui.View
 }
 
 func (gui *Gui) openContainerInBrowser(ctr *commands.Container) error {
-	// skip if no any ports
-	if len(ctr.Container.Ports) == 0 {
-		return nil
-	}
-	// skip if the first port is not published
-	port := ctr.Container.Ports[0]
-	if port.IP == "" {
+	// skip if there are no published http-capable ports
+	urls := ctr.PortURLs()
+	if len(urls) == 0 {
 		return nil
 	}
-	ip := port.IP
-	if ip == "0.0.0.0" {
-		ip = "localhost"
-	}
-	link := fmt.Sprintf("http://%s:%d/", ip, port.PublicPort)
-	return gui.OSCommand.OpenLink(link)
+	return gui.OSCommand.OpenLink(urls[0])
 }
@@ -19,6 +19,7 @@ import (
 	"github.com/jesseduffield/lazydocker/pkg/gui/types"
 	"github.com/jesseduffield/lazydocker/pkg/i18n"
 	"github.com/jesseduffield/lazydocker/pkg/tasks"
+	"github.com/jesseduffield/lazydocker/pkg/utils"
 	"github.com/sasha-s/go-deadlock"
 	"github.com/sirupsen/logrus"
 )
@@ -45,6 +46,10 @@ type Gui struct {
 	Mutexes
 
 	Panels Panels
+
+	// logsWriter is what the logs currently being streamed are written
+	// through, so that we can pause them. Guarded by LogsWriterMutex
+	logsWriter *utils.PausableWriter
 }
 
 type Panels struct {
@@ -60,6 +65,7 @@ type Panels struct {
 type Mutexes struct {
 	SubprocessMutex deadlock.Mutex
 	ViewStackMutex  deadlock.Mutex
+	LogsWriterMutex deadlock.Mutex
 }
 
 type mainPanelState struct {
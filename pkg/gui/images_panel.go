@@ -82,12 +82,35 @@ func (gui *Gui) imageConfigStr(image *commands.Image) string {
 	output += utils.WithPadding("Size: ", padding) + utils.FormatDecimalBytes(int(image.Image.Size)) + "\n"
 	output += utils.WithPadding("Created: ", padding) + fmt.Sprintf("%v", time.Unix(image.Image.Created, 0).Format(time.RFC1123)) + "\n"
 
-	history, err := image.RenderHistory()
-	if err != nil {
-		gui.Log.Error(err)
+	// this gets re-rendered often, so we fetch the history once and use it for
+	// both the base image and the history table, and we go off the containers
+	// we've already loaded rather than listing them again
+	history, historyErr := image.History()
+	if historyErr != nil {
+		gui.Log.Error(historyErr)
+	}
+
+	if baseRef := image.BaseRef(history); baseRef != "" {
+		output += utils.WithPadding("Base: ", padding) + baseRef + "\n"
+	}
+
+	containers := lo.Filter(gui.Panels.Containers.List.GetAllItems(), func(ctr *commands.Container, _ int) bool {
+		return ctr.UsesImage(image.ID)
+	})
+	if len(containers) > 0 {
+		names := lo.Map(containers, func(ctr *commands.Container, _ int) string { return ctr.Name })
+		output += utils.WithPadding("Used By: ", padding) + strings.Join(names, ", ") + "\n"
 	}
 
-	output += "\n\n" + history
+	if historyErr == nil {
+		historyTable, err := commands.RenderHistory(history)
+		if err != nil {
+			gui.Log.Error(err)
+		}
+		output += "\n\n" + historyTable
+	} else {
+		output += "\n\n"
+	}
 
 	return output
 }
@@ -164,11 +187,25 @@ func (gui *Gui) handleImagesRemoveMenu(g *gocui.Gui, v *gocui.View) error {
 				color.New(color.FgRed).Sprint(option.command),
 			},
 			OnPress: func() error {
-				if err := img.Remove(option.configOptions); err != nil {
+				if !option.configOptions.Force {
+					return gui.removeImage(img, option.configOptions)
+				}
+
+				// docker won't stop us force removing an image that containers
+				// were created from, so we warn about it ourselves
+				containers, err := gui.DockerCommand.ContainersForImage(img.ID)
+				if err != nil {
 					return gui.createErrorPanel(err.Error())
 				}
+				if len(containers) == 0 {
+					return gui.removeImage(img, option.configOptions)
+				}
 
-				return nil
+				names := lo.Map(containers, func(ctr *commands.Container, _ int) string { return ctr.Name })
+				prompt := gui.Tr.ConfirmForceRemoveUsedImage + "\n\n" + strings.Join(names, "\n")
+				return gui.createConfirmationPanel(gui.Tr.Confirm, prompt, func(g *gocui.Gui, v *gocui.View) error {
+					return gui.removeImage(img, option.configOptions)
+				}, nil)
 			},
 		}
 	})
@@ -179,6 +216,14 @@ func (gui *Gui) handleImagesRemoveMenu(g *gocui.Gui, v *gocui.View) error {
 	})
 }
 
+func (gui *Gui) removeImage(img *commands.Image, options image.RemoveOptions) error {
+	if err := img.Remove(options); err != nil {
+		return gui.createErrorPanel(err.Error())
+	}
+
+	return nil
+}
+
 func (gui *Gui) handlePruneImages() error {
 	return gui.createConfirmationPanel(gui.Tr.Confirm, gui.Tr.ConfirmPruneImages, func(g *gocui.Gui, v *gocui.View) error {
 		return gui.WithWaitingStatus(gui.Tr.PruningStatus, func() error {
@@ -9,6 +9,7 @@ import (
 func (gui *Gui) scrollUpMain() error {
 	mainView := gui.Views.Main
 	mainView.Autoscroll = false
+	gui.pauseLogs()
 	ox, oy := mainView.Origin()
 	newOy := int(math.Max(0, float64(oy-gui.Config.UserConfig.Gui.ScrollHeight)))
 	return mainView.SetOrigin(ox, newOy)
@@ -63,11 +64,12 @@ func (gui *Gui) scrollRightMain(g *gocui.Gui, v *gocui.View) error {
 
 func (gui *Gui) autoScrollMain(g *gocui.Gui, v *gocui.View) error {
 	gui.Views.Main.Autoscroll = true
-	return nil
+	return gui.resumeLogs()
 }
 
 func (gui *Gui) jumpToTopMain(g *gocui.Gui, v *gocui.View) error {
 	gui.Views.Main.Autoscroll = false
+	gui.pauseLogs()
 	_ = gui.Views.Main.SetOrigin(0, 0)
 	_ = gui.Views.Main.SetCursor(0, 0)
 	return nil
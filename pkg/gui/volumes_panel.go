@@ -82,6 +82,22 @@ func (gui *Gui) volumeConfigStr(volume *commands.Volume) string {
 		output += utils.WithPadding("Size: ", padding) + utils.FormatBinaryBytes(int(volume.Volume.UsageData.Size)) + "\n"
 	}
 
+	output += "\n" + utils.WithPadding("Mounted By: ", padding)
+	// this gets re-rendered often, so we go off the containers we've already
+	// loaded rather than listing them again
+	mounts := []commands.VolumeMount{}
+	for _, ctr := range gui.Panels.Containers.List.GetAllItems() {
+		mounts = append(mounts, ctr.VolumeMounts(volume.Name)...)
+	}
+	if len(mounts) == 0 {
+		output += "none\n"
+	} else {
+		output += "\n"
+		for _, mount := range mounts {
+			output += utils.FormatMapItem(padding, mount.ContainerName, mount.Destination)
+		}
+	}
+
 	return output
 }
 
@@ -94,6 +94,7 @@ type TranslationSet struct {
 	ConfirmStopContainers       string
 	ConfirmRemoveContainers     string
 	ConfirmPruneImages          string
+	ConfirmForceRemoveUsedImage string
 	ConfirmPruneVolumes         string
 	ConfirmPruneNetworks        string
 	PruningStatus               string
@@ -254,6 +255,7 @@ func englishSet() TranslationSet {
 		MustForceToRemoveContainer:  "You cannot remove a running container unless you force it. Do you want to force it?",
 		NotEnoughSpace:              "Not enough space to render panels",
 		ConfirmPruneImages:          "Are you sure you want to prune all unused images?",
+		ConfirmForceRemoveUsedImage: "This image is used by the containers below, which will be left without it. Are you sure you want to force remove it?",
 		ConfirmPruneContainers:      "Are you sure you want to prune all stopped containers?",
 		ConfirmStopContainers:       "Are you sure you want to stop all containers?",
 		ConfirmRemoveContainers:     "Are you sure you want to remove all containers?",
@@ -10,6 +10,7 @@ import (
 	"regexp"
 	"sort"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/go-errors/errors"
@@ -164,6 +165,17 @@ func Decolorise(str string) string {
 	return re.ReplaceAllString(str, "")
 }
 
+// ansiEscapeRegexp matches CSI sequences (colours, cursor movement, erasing)
+// and OSC sequences (e.g. hyperlinks and window titles)
+var ansiEscapeRegexp = regexp.MustCompile(`\x1B(\[[0-9;?]*[ -/]*[@-~]|\][^\x07\x1B]*(\x07|\x1B\\))`)
+
+// StripANSI removes all ANSI escape sequences from a string. Unlike Decolorise,
+// which only deals with the colour codes we produce ourselves, this is for
+// output from arbitrary programs e.g. container logs
+func StripANSI(str string) string {
+	return ansiEscapeRegexp.ReplaceAllString(str, "")
+}
+
 func getPadWidths(rows [][]string) []int {
 	if len(rows[0]) <= 1 {
 		return []int{}
@@ -410,3 +422,184 @@ func marshalIntoFormat(data interface{}, format string) ([]byte, error) {
 		return nil, errors.New(fmt.Sprintf("Unsupported detailization format: %s", format))
 	}
 }
+
+// BatchingWriter collects whatever is written to it and passes it on to the
+// underlying writer at most once per interval, for when each write to the
+// underlying writer is expensive (e.g. triggers a redraw)
+type BatchingWriter struct {
+	mutex  sync.Mutex
+	writer io.Writer
+	buf    bytes.Buffer
+	err    error
+
+	stop    chan struct{}
+	stopped chan struct{}
+}
+
+var _ io.WriteCloser = &BatchingWriter{}
+
+// NewBatchingWriter returns a BatchingWriter that flushes every interval. It
+// must be closed to flush any remaining output and stop its goroutine
+func NewBatchingWriter(writer io.Writer, interval time.Duration) *BatchingWriter {
+	w := &BatchingWriter{
+		writer:  writer,
+		stop:    make(chan struct{}),
+		stopped: make(chan struct{}),
+	}
+
+	go func() {
+		defer close(w.stopped)
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-w.stop:
+				return
+			case <-ticker.C:
+				w.flush()
+			}
+		}
+	}()
+
+	return w
+}
+
+// Write buffers p until the next flush. If a previous flush failed, we return
+// its error so that whoever is writing to us knows to stop
+func (w *BatchingWriter) Write(p []byte) (int, error) {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	if w.err != nil {
+		return 0, w.err
+	}
+
+	return w.buf.Write(p)
+}
+
+func (w *BatchingWriter) flush() {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	if w.err != nil || w.buf.Len() == 0 {
+		return
+	}
+
+	_, w.err = w.writer.Write(w.buf.Bytes())
+	w.buf.Reset()
+}
+
+// Close flushes anything still buffered and stops flushing
+func (w *BatchingWriter) Close() error {
+	close(w.stop)
+	<-w.stopped
+	w.flush()
+
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	return w.err
+}
+
+// PausableWriter passes writes straight through to the underlying writer until
+// it's paused, at which point it holds on to what's written until it's resumed.
+// This lets us stop e.g. a logs view from moving without tearing down the
+// stream feeding it. To bound memory we only hold on to the most recent
+// maxLines lines, and when we have to drop older lines we say how many when
+// resuming.
+type PausableWriter struct {
+	mutex    sync.Mutex
+	writer   io.Writer
+	maxLines int
+
+	paused bool
+	lines  [][]byte
+	// partial is the start of a line we haven't seen the end of yet
+	partial []byte
+	dropped int
+}
+
+var _ io.Writer = &PausableWriter{}
+
+// maxPartialLineBytes is how much of a line we'll hold on to while paused
+// before treating it as a line of its own, so that a stream with no newlines
+// can't grow our buffer without bound
+const maxPartialLineBytes = 64 * 1024
+
+// NewPausableWriter returns a PausableWriter which starts off unpaused and
+// holds on to at most maxLines lines while paused
+func NewPausableWriter(writer io.Writer, maxLines int) *PausableWriter {
+	return &PausableWriter{writer: writer, maxLines: maxLines}
+}
+
+// Write passes p straight through to the underlying writer unless we're
+// paused, in which case it's held on to until Resume is called
+func (w *PausableWriter) Write(p []byte) (int, error) {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	if !w.paused {
+		return w.writer.Write(p)
+	}
+
+	data := append(w.partial, p...)
+	for {
+		i := bytes.IndexByte(data, '\n')
+		if i == -1 {
+			break
+		}
+		w.lines = append(w.lines, data[:i+1])
+		data = data[i+1:]
+	}
+	for len(data) > maxPartialLineBytes {
+		w.lines = append(w.lines, data[:maxPartialLineBytes])
+		data = data[maxPartialLineBytes:]
+	}
+	w.partial = append([]byte{}, data...)
+
+	if overflow := len(w.lines) - w.maxLines; overflow > 0 {
+		w.lines = w.lines[overflow:]
+		w.dropped += overflow
+	}
+
+	return len(p), nil
+}
+
+// Pause holds on to anything written from now on until Resume is called
+func (w *PausableWriter) Pause() {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	w.paused = true
+}
+
+// Resume writes out everything held on to since pausing, preceded by a marker
+// if we had to drop any lines, and goes back to passing writes straight through
+func (w *PausableWriter) Resume() error {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	if !w.paused {
+		return nil
+	}
+	w.paused = false
+
+	var buf bytes.Buffer
+	if w.dropped > 0 {
+		fmt.Fprintf(&buf, "[%d lines dropped]\n", w.dropped)
+	}
+	for _, line := range w.lines {
+		buf.Write(line)
+	}
+	buf.Write(w.partial)
+
+	w.lines = nil
+	w.partial = nil
+	w.dropped = 0
+
+	if buf.Len() == 0 {
+		return nil
+	}
+	_, err := w.writer.Write(buf.Bytes())
+	return err
+}
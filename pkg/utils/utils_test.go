@@ -1,7 +1,10 @@
 package utils
 
 import (
+	"strings"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/go-errors/errors"
 	"github.com/stretchr/testify/assert"
@@ -319,3 +322,125 @@ quux:
 		}
 	}
 }
+
+func TestStripANSI(t *testing.T) {
+	type scenario struct {
+		input    string
+		expected string
+	}
+
+	scenarios := []scenario{
+		{
+			"plain log line",
+			"plain log line",
+		},
+		{
+			"\x1b[32mINFO\x1b[0m server started",
+			"INFO server started",
+		},
+		{
+			"\x1b[1;31;40mERROR\x1b[m \x1b[38;5;208mdisk\x1b[39m full\x1b[K",
+			"ERROR disk full",
+		},
+		{
+			"\x1b]8;;http://localhost\x07link\x1b]8;;\x07 \x1b[2Adone",
+			"link done",
+		},
+	}
+
+	for _, s := range scenarios {
+		assert.EqualValues(t, s.expected, StripANSI(s.input))
+	}
+}
+
+type recordingWriter struct {
+	mutex  sync.Mutex
+	writes []string
+}
+
+func (w *recordingWriter) Write(p []byte) (int, error) {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	w.writes = append(w.writes, string(p))
+	return len(p), nil
+}
+
+func (w *recordingWriter) getWrites() []string {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	return append([]string{}, w.writes...)
+}
+
+func TestBatchingWriter(t *testing.T) {
+	underlying := &recordingWriter{}
+	writer := NewBatchingWriter(underlying, time.Hour)
+
+	for _, line := range []string{"one\n", "two\n", "three\n"} {
+		_, err := writer.Write([]byte(line))
+		assert.NoError(t, err)
+	}
+	assert.Empty(t, underlying.getWrites())
+
+	// closing flushes whatever is left in one go
+	assert.NoError(t, writer.Close())
+	assert.EqualValues(t, []string{"one\ntwo\nthree\n"}, underlying.getWrites())
+}
+
+func TestBatchingWriterFlushesOnInterval(t *testing.T) {
+	underlying := &recordingWriter{}
+	writer := NewBatchingWriter(underlying, 10*time.Millisecond)
+	defer writer.Close()
+
+	_, _ = writer.Write([]byte("one\n"))
+	_, _ = writer.Write([]byte("two\n"))
+	assert.Eventually(t, func() bool { return len(underlying.getWrites()) > 0 }, time.Second, time.Millisecond)
+	assert.EqualValues(t, "one\ntwo\n", strings.Join(underlying.getWrites(), ""))
+}
+
+func TestPausableWriter(t *testing.T) {
+	underlying := &recordingWriter{}
+	writer := NewPausableWriter(underlying, 3)
+
+	_, err := writer.Write([]byte("before\n"))
+	assert.NoError(t, err)
+	assert.EqualValues(t, []string{"before\n"}, underlying.getWrites())
+
+	writer.Pause()
+	for _, chunk := range []string{"one\ntwo\n", "three\n", "fo", "ur\nfive\n", "six"} {
+		n, err := writer.Write([]byte(chunk))
+		assert.NoError(t, err)
+		assert.EqualValues(t, len(chunk), n)
+	}
+	assert.EqualValues(t, []string{"before\n"}, underlying.getWrites())
+
+	assert.NoError(t, writer.Resume())
+	assert.EqualValues(t, []string{"before\n", "[2 lines dropped]\nthree\nfour\nfive\nsix"}, underlying.getWrites())
+
+	_, err = writer.Write([]byte(" continued\n"))
+	assert.NoError(t, err)
+	assert.EqualValues(t, " continued\n", underlying.getWrites()[2])
+
+	// resuming when nothing was written while paused writes nothing
+	writer.Pause()
+	assert.NoError(t, writer.Resume())
+	assert.Len(t, underlying.getWrites(), 3)
+}
+
+func TestPausableWriterCapsPartialLines(t *testing.T) {
+	underlying := &recordingWriter{}
+	writer := NewPausableWriter(underlying, 2)
+
+	writer.Pause()
+	// a stream with no newlines still only holds on to maxLines lines' worth
+	chunk := strings.Repeat("x", maxPartialLineBytes)
+	for i := 0; i < 5; i++ {
+		_, err := writer.Write([]byte(chunk))
+		assert.NoError(t, err)
+	}
+	assert.LessOrEqual(t, len(writer.partial), maxPartialLineBytes)
+	assert.Len(t, writer.lines, 2)
+
+	assert.NoError(t, writer.Resume())
+	// comparing with assert.True to keep a failure's output readable
+	assert.True(t, underlying.getWrites()[0] == "[2 lines dropped]\n"+strings.Repeat(chunk, 3))
+}